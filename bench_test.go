@@ -0,0 +1,72 @@
+package tasker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// BenchmarkDispatch Пропускная способность менеджера при разном количестве задач в очереди
+// Метрика tasks/s - количество выполненных задач в секунду, cpu-ns/task - процессорное время на задачу
+func BenchmarkDispatch(b *testing.B) {
+	for _, n := range []int{10, 1000, 100000} {
+		b.Run(fmt.Sprintf("queued=%d", n), func(b *testing.B) {
+			var tasks Tasker
+			var data = make([]interface{}, n)
+			var begin time.Time
+			var cpu time.Duration
+
+			for i := range data {
+				data[i] = i
+			}
+			tasks = NewTasker().
+				Concurrent(8).
+				Worker(func(in interface{}) error { return nil })
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				_ = tasks.AddTasks(data)
+				b.StartTimer()
+				begin, cpu = time.Now(), cpuTime()
+				tasks.Run().Wait()
+				cpu = cpuTime() - cpu
+				b.ReportMetric(float64(n)/time.Since(begin).Seconds(), "tasks/s")
+				if cpu >= 0 {
+					b.ReportMetric(float64(cpu.Nanoseconds())/float64(n), "cpu-ns/task")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkIdle Потребление процессора запущенным таскером, ожидающим отложенные задачи
+// Метрика cpu-% - доля процессорного времени от прошедшего времени
+func BenchmarkIdle(b *testing.B) {
+	for _, n := range []int{10, 1000, 100000} {
+		b.Run(fmt.Sprintf("queued=%d", n), func(b *testing.B) {
+			var tasks Tasker
+			var begin time.Time
+			var cpu time.Duration
+
+			tasks = NewTasker().
+				Concurrent(8).
+				Worker(func(in interface{}) error { return nil })
+			for i := 0; i < n; i++ {
+				_ = tasks.AddTaskAfter(i, time.Hour)
+			}
+			tasks.Run()
+			time.Sleep(time.Second / 10)
+			b.ResetTimer()
+			begin, cpu = time.Now(), cpuTime()
+			for i := 0; i < b.N; i++ {
+				time.Sleep(time.Millisecond * 10)
+			}
+			cpu = cpuTime() - cpu
+			if cpu >= 0 {
+				b.ReportMetric(100*cpu.Seconds()/time.Since(begin).Seconds(), "cpu-%")
+			}
+			b.StopTimer()
+			tasks.Interrupt().Wait()
+		})
+	}
+}
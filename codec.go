@@ -0,0 +1,228 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Codec Сериализация объектов задач для хранения и передачи за пределы процесса
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)      // Сериализация объекта задачи или указателя на переменную интерфейсного типа с объектом задачи
+	Unmarshal(data []byte, v interface{}) error // Восстановление объекта задачи по указателю v
+}
+
+// Types Реестр именованных типов объектов задач
+// Сериализованный объект задачи содержит имя своего типа, поэтому объекты разных типов восстанавливаются
+// в переменную типа interface{}, если их типы зарегистрированы. Таскер с объектами задач интерфейсного типа
+// передаёт в Marshal указатель на объект задачи, и объект не зарегистрированного типа не сериализуется с ошибкой
+// ErrUnregisteredType. Сериализация и восстановление объектов конкретного типа регистрации не требуют
+type Types struct {
+	Names map[reflect.Type]string // Имена зарегистрированных типов
+	Kinds map[string]reflect.Type // Зарегистрированные типы по именам
+	sync.RWMutex
+}
+
+// Register Регистрация типа объекта sample под именем name
+// Повторная регистрация того же типа под тем же именем не является ошибкой
+func (ts *Types) Register(name string, sample interface{}) (err error) {
+	var typ reflect.Type
+	var known reflect.Type
+	var ok bool
+
+	if name == "" || sample == nil {
+		err = fmt.Errorf("Task body type name or sample is empty")
+		return
+	}
+	typ = reflect.TypeOf(sample)
+	ts.Lock()
+	defer ts.Unlock()
+	if ts.Names == nil {
+		ts.Names, ts.Kinds = make(map[reflect.Type]string), make(map[string]reflect.Type)
+	}
+	if known, ok = ts.Kinds[name]; ok && known != typ {
+		err = fmt.Errorf("Task body type name %q already registered for type %s", name, known)
+		return
+	}
+	if _, ok = ts.Names[typ]; ok && ts.Names[typ] != name {
+		err = fmt.Errorf("Task body type %s already registered as %q", typ, ts.Names[typ])
+		return
+	}
+	ts.Names[typ], ts.Kinds[name] = name, typ
+	return
+}
+
+// Name Имя типа объекта, для не зарегистрированного типа возвращается имя типа Go
+func (ts *Types) Name(v interface{}) string {
+	var typ = reflect.TypeOf(v)
+
+	ts.RLock()
+	defer ts.RUnlock()
+	if name, ok := ts.Names[typ]; ok {
+		return name
+	}
+	return fmt.Sprint(typ)
+}
+
+// Encode Имя типа и объект для сериализации
+// Для указателя на переменную интерфейсного типа сериализуется объект из переменной, тип которого должен быть зарегистрирован
+func (ts *Types) Encode(v interface{}) (name string, body interface{}, err error) {
+	var rv = reflect.ValueOf(v)
+	var ok bool
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Interface {
+		name, body = ts.Name(v), v
+		return
+	}
+	body = rv.Elem().Interface()
+	ts.RLock()
+	name, ok = ts.Names[reflect.TypeOf(body)]
+	ts.RUnlock()
+	if !ok {
+		err = fmt.Errorf("%w: %T", ErrUnregisteredType, body)
+	}
+	return
+}
+
+// Restore Восстановление объекта типа name по указателю v функцией decode
+// Для указателя на интерфейс создаётся объект зарегистрированного типа, для указателя на конкретный тип имя типа не используется
+func (ts *Types) Restore(name string, v interface{}, decode func(interface{}) error) (err error) {
+	var rv = reflect.ValueOf(v)
+	var typ reflect.Type
+	var obj reflect.Value
+	var ok bool
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		err = fmt.Errorf("Task body must be restored into a non-nil pointer, got %T", v)
+		return
+	}
+	if rv.Elem().Kind() != reflect.Interface {
+		return decode(v)
+	}
+	ts.RLock()
+	// Объект сериализованный из переменной конкретного типа до регистрации своего типа содержит имя типа Go
+	if typ, ok = ts.Kinds[name]; !ok {
+		for known := range ts.Names {
+			if ok = known.String() == name; ok {
+				typ = known
+				break
+			}
+		}
+	}
+	ts.RUnlock()
+	if !ok {
+		err = fmt.Errorf("%w: %q", ErrUnregisteredType, name)
+		return
+	}
+	if !typ.AssignableTo(rv.Elem().Type()) {
+		err = fmt.Errorf("Task body type %q does not implement %s", name, rv.Elem().Type())
+		return
+	}
+	obj = reflect.New(typ)
+	if err = decode(obj.Interface()); err != nil {
+		return
+	}
+	rv.Elem().Set(obj.Elem())
+	return
+}
+
+// JSONCodec Сериализация объектов задач в JSON с именем типа объекта
+type JSONCodec struct {
+	Types
+}
+
+// jsonEnvelope Объект задачи в JSON с именем типа
+type jsonEnvelope struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// NewJSONCodec Создание сериализации объектов задач в JSON
+func NewJSONCodec() *JSONCodec { return new(JSONCodec) }
+
+// Marshal Сериализация объекта задачи в JSON
+func (jc *JSONCodec) Marshal(v interface{}) (ret []byte, err error) {
+	var env jsonEnvelope
+
+	if env.Type, v, err = jc.Encode(v); err != nil {
+		return
+	}
+	if env.Data, err = json.Marshal(v); err != nil {
+		err = fmt.Errorf("Encode task body %q error: %w", env.Type, err)
+		return
+	}
+	ret, err = json.Marshal(env)
+	return
+}
+
+// Unmarshal Восстановление объекта задачи из JSON
+func (jc *JSONCodec) Unmarshal(data []byte, v interface{}) (err error) {
+	var env jsonEnvelope
+
+	if err = json.Unmarshal(data, &env); err != nil {
+		err = fmt.Errorf("Decode task body error: %w", err)
+		return
+	}
+	return jc.Restore(env.Type, v, func(obj interface{}) (err error) {
+		if err = json.Unmarshal(env.Data, obj); err != nil {
+			err = fmt.Errorf("Decode task body %q error: %w", env.Type, err)
+		}
+		return
+	})
+}
+
+// GobCodec Сериализация объектов задач в gob с именем типа объекта
+type GobCodec struct {
+	Types
+}
+
+// gobEnvelope Объект задачи в gob с именем типа
+type gobEnvelope struct {
+	Type string
+	Data []byte
+}
+
+// NewGobCodec Создание сериализации объектов задач в gob
+func NewGobCodec() *GobCodec { return new(GobCodec) }
+
+// Marshal Сериализация объекта задачи в gob
+func (gc *GobCodec) Marshal(v interface{}) (ret []byte, err error) {
+	var env gobEnvelope
+	var buf bytes.Buffer
+
+	if env.Type, v, err = gc.Encode(v); err != nil {
+		return
+	}
+	if err = gob.NewEncoder(&buf).Encode(v); err != nil {
+		err = fmt.Errorf("Encode task body %q error: %w", env.Type, err)
+		return
+	}
+	env.Data = buf.Bytes()
+	buf = bytes.Buffer{}
+	if err = gob.NewEncoder(&buf).Encode(env); err != nil {
+		return
+	}
+	ret = buf.Bytes()
+	return
+}
+
+// Unmarshal Восстановление объекта задачи из gob
+func (gc *GobCodec) Unmarshal(data []byte, v interface{}) (err error) {
+	var env gobEnvelope
+
+	if err = gob.NewDecoder(bytes.NewReader(data)).Decode(&env); err != nil {
+		err = fmt.Errorf("Decode task body error: %w", err)
+		return
+	}
+	return gc.Restore(env.Type, v, func(obj interface{}) (err error) {
+		if err = gob.NewDecoder(bytes.NewReader(env.Data)).Decode(obj); err != nil {
+			err = fmt.Errorf("Decode task body %q error: %w", env.Type, err)
+		}
+		return
+	})
+}
@@ -0,0 +1,133 @@
+package tasker
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+type codecOrder struct {
+	ID    int
+	Items []string
+}
+
+type codecRefund struct {
+	Order  int
+	Amount float64
+}
+
+// testCodec Восстановление объектов задач разных типов в переменную interface{} и в переменную конкретного типа
+func testCodec(t *testing.T, codec Codec, types *Types) {
+	var data []byte
+	var body interface{}
+	var order codecOrder
+	var err error
+
+	if err = types.Register("order", codecOrder{}); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if err = types.Register("refund", &codecRefund{}); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if types.Register("order", codecRefund{}) == nil || types.Register("other", codecOrder{}) == nil {
+		t.Fatalf("Conflicting registration accepted")
+	}
+	for _, in := range []interface{}{codecOrder{ID: 1, Items: []string{"a", "b"}}, &codecRefund{Order: 1, Amount: 2.5}} {
+		if data, err = codec.Marshal(in); err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		}
+		body = nil
+		if err = codec.Unmarshal(data, &body); err != nil {
+			t.Fatalf("Unmarshal error: %v", err)
+		}
+		switch v := body.(type) {
+		case codecOrder:
+			if v.ID != 1 || len(v.Items) != 2 {
+				t.Fatalf("Unexpected order: %+v", v)
+			}
+		case *codecRefund:
+			if v.Amount != 2.5 {
+				t.Fatalf("Unexpected refund: %+v", v)
+			}
+		default:
+			t.Fatalf("Unexpected body type %T", body)
+		}
+	}
+	// Восстановление в переменную конкретного типа не требует регистрации
+	if data, err = codec.Marshal(struct{ ID int }{ID: 7}); err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if err = codec.Unmarshal(data, &order); err != nil || order.ID != 7 {
+		t.Fatalf("Unexpected concrete body %+v: %v", order, err)
+	}
+	if err = codec.Unmarshal(data, &body); !errors.Is(err, ErrUnregisteredType) {
+		t.Fatalf("Unexpected error for unregistered type: %v", err)
+	}
+	// Объект из переменной интерфейсного типа сериализуется только для зарегистрированного типа
+	body = struct{ ID int }{ID: 7}
+	if _, err = codec.Marshal(&body); !errors.Is(err, ErrUnregisteredType) {
+		t.Fatalf("Unexpected marshal error for unregistered type: %v", err)
+	}
+	body = &codecRefund{Order: 2}
+	if data, err = codec.Marshal(&body); err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if body = nil; codec.Unmarshal(data, &body) != nil || body.(*codecRefund).Order != 2 {
+		t.Fatalf("Unexpected body restored from interface variable: %+v", body)
+	}
+}
+
+// TestJSONCodec Сериализация объектов задач в JSON
+func TestJSONCodec(t *testing.T) {
+	var codec = NewJSONCodec()
+
+	testCodec(t, codec, &codec.Types)
+}
+
+// TestGobCodec Сериализация объектов задач в gob
+func TestGobCodec(t *testing.T) {
+	var codec = NewGobCodec()
+
+	testCodec(t, codec, &codec.Types)
+}
+
+// TestPersistTypes Восстановление задач разных типов таскером с объектами задач произвольного типа
+func TestPersistTypes(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "tasks.db")
+	var codec = NewGobCodec()
+	var store Store
+	var tasks Tasker
+	var done []interface{}
+	var err error
+
+	codec.Register("order", codecOrder{})
+	if store, err = OpenDBStore(path); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	if tasks, err = NewTaskerFrom(store, codec); err != nil {
+		t.Fatalf("NewTaskerFrom error: %v", err)
+	}
+	if err = tasks.AddTask(codecOrder{ID: 1}); err != nil {
+		t.Fatalf("AddTask error: %v", err)
+	}
+	// Задача не зарегистрированного типа не добавляется и не мешает восстановлению остальных задач
+	if err = tasks.AddTask(&codecRefund{Order: 1}); !errors.Is(err, ErrUnregisteredType) {
+		t.Fatalf("Unexpected error for unregistered type: %v", err)
+	}
+	codec.Register("refund", &codecRefund{})
+	if err = tasks.AddTask(&codecRefund{Order: 1}); err != nil {
+		t.Fatalf("AddTask error: %v", err)
+	}
+	store.Close()
+	if store, err = OpenDBStore(path); err != nil {
+		t.Fatalf("Reopen error: %v", err)
+	}
+	defer store.Close()
+	if tasks, err = NewTaskerFrom(store, codec); err != nil {
+		t.Fatalf("Recovery error: %v", err)
+	}
+	tasks.Concurrent(1).Worker(func(in interface{}) error { done = append(done, in); return nil }).Run().Wait()
+	if len(done) != 2 || done[0].(codecOrder).ID != 1 || done[1].(*codecRefund).Order != 1 {
+		t.Fatalf("Unexpected recovered tasks: %+v", done)
+	}
+}
@@ -0,0 +1,68 @@
+package tasker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestInterruptCancelContext Прерывание должно отменять контексты выполняющихся задач
+func TestInterruptCancelContext(t *testing.T) {
+	var tasks Tasker
+	var canceled = make(chan interface{}, 10)
+	var started = make(chan interface{}, 10)
+
+	tasks = NewTasker().
+		Concurrent(2).
+		WorkerCtx(func(ctx context.Context, in interface{}) error {
+			started <- true
+			<-ctx.Done()
+			canceled <- true
+			return ctx.Err()
+		})
+	_ = tasks.AddTasks([]interface{}{1, 2})
+	if err := tasks.Run().Error(); err != nil {
+		t.Fatalf("Error run tasker: %v", err)
+	}
+	<-started
+	<-started
+	tasks.Interrupt().Wait()
+	if len(canceled) != 2 {
+		t.Fatalf("Context of running tasks not canceled: %d", len(canceled))
+	}
+}
+
+// TestRunContextCancel Отмена родительского контекста прерывает выполнение задач
+func TestRunContextCancel(t *testing.T) {
+	var tasks Tasker
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var bootstrapCtx context.Context
+
+	ctx, cancel = context.WithCancel(context.Background())
+	tasks = NewTasker().
+		Concurrent(1).
+		BootstrapCtx(func(ctx context.Context, in []interface{}) error {
+			bootstrapCtx = ctx
+			return nil
+		}).
+		WorkerCtx(func(ctx context.Context, in interface{}) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	_ = tasks.AddTask(1)
+	if err := tasks.RunContext(ctx).Error(); err != nil {
+		t.Fatalf("Error run tasker: %v", err)
+	}
+	if bootstrapCtx == nil {
+		t.Fatalf("Bootstrap function not received context")
+	}
+	time.AfterFunc(time.Second/10, cancel)
+	tasks.Wait()
+	if tasks.IsWork() {
+		t.Fatalf("Tasker still work after cancel parent context")
+	}
+	if bootstrapCtx.Err() == nil {
+		t.Fatalf("Bootstrap context not canceled")
+	}
+}
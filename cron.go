@@ -0,0 +1,254 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule Расписание периодического запуска
+type Schedule interface {
+	// Next Время следующего запуска строго после указанного времени, нулевое время если запусков больше не будет
+	Next(time.Time) time.Time
+}
+
+// cronField Описание поля cron выражения
+type cronField struct {
+	Min, Max int            // Допустимый диапазон значений
+	Names    map[string]int // Допустимые имена значений
+}
+
+var (
+	cronSeconds = cronField{Min: 0, Max: 59}
+	cronMinutes = cronField{Min: 0, Max: 59}
+	cronHours   = cronField{Min: 0, Max: 23}
+	cronDom     = cronField{Min: 1, Max: 31}
+	cronMonths  = cronField{Min: 1, Max: 12, Names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDow = cronField{Min: 0, Max: 7, Names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// cronSchedule Расписание заданное cron выражением, каждое поле хранится битовой маской допустимых значений
+type cronSchedule struct {
+	Second, Minute, Hour, Dom, Month, Dow uint64
+	DomAny, DowAny                        bool           // =true - поле дня месяца или дня недели задано как "*"
+	Location                              *time.Location // Часовой пояс расписания, nil - часовой пояс переданного времени
+}
+
+// everySchedule Расписание с постоянным интервалом
+type everySchedule struct {
+	Interval time.Duration
+}
+
+// ParseCron Разбор cron выражения
+// Поддерживаются выражения из 5 полей (минуты, часы, день месяца, месяц, день недели),
+// из 6 полей (первым полем секунды), описатели @yearly, @monthly, @weekly, @daily, @hourly,
+// интервалы @every <длительность> и указание часового пояса префиксом CRON_TZ=<зона> или TZ=<зона>
+func ParseCron(spec string) (ret Schedule, err error) {
+	var cs = new(cronSchedule)
+	var fields []string
+	var d time.Duration
+	var desc string
+	var ok bool
+	var i int
+
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i = strings.IndexAny(spec, " \t")
+		if i < 0 {
+			err = fmt.Errorf("Invalid cron expression %q: missing schedule after time zone", spec)
+			return
+		}
+		if cs.Location, err = time.LoadLocation(spec[strings.Index(spec, "=")+1 : i]); err != nil {
+			err = fmt.Errorf("Invalid cron expression %q: %s", spec, err)
+			return
+		}
+		spec = strings.TrimSpace(spec[i:])
+	}
+	if strings.HasPrefix(spec, "@every ") {
+		if d, err = time.ParseDuration(strings.TrimSpace(spec[len("@every "):])); err != nil || d <= 0 {
+			err = fmt.Errorf("Invalid cron interval %q", spec)
+			return
+		}
+		ret = everySchedule{Interval: d}
+		return
+	}
+	if strings.HasPrefix(spec, "@") {
+		if desc, ok = cronDescriptors[strings.ToLower(spec)]; !ok {
+			err = fmt.Errorf("Unknown cron descriptor %q", spec)
+			return
+		}
+		spec = desc
+	}
+	switch fields = strings.Fields(spec); len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		err = fmt.Errorf("Invalid cron expression %q: expected 5 or 6 fields, got %d", spec, len(fields))
+		return
+	}
+	if cs.Second, err = cronSeconds.Parse(fields[0]); err != nil {
+		return
+	}
+	if cs.Minute, err = cronMinutes.Parse(fields[1]); err != nil {
+		return
+	}
+	if cs.Hour, err = cronHours.Parse(fields[2]); err != nil {
+		return
+	}
+	if cs.Dom, err = cronDom.Parse(fields[3]); err != nil {
+		return
+	}
+	if cs.Month, err = cronMonths.Parse(fields[4]); err != nil {
+		return
+	}
+	if cs.Dow, err = cronDow.Parse(fields[5]); err != nil {
+		return
+	}
+	// Воскресенье может быть задано как 0 и как 7
+	if cs.Dow&(1<<7) != 0 {
+		cs.Dow |= 1
+	}
+	cs.DomAny = fields[3] == "*" || fields[3] == "?"
+	cs.DowAny = fields[5] == "*" || fields[5] == "?"
+	ret = cs
+
+	return
+}
+
+// Parse Разбор поля cron выражения в битовую маску
+func (f cronField) Parse(field string) (ret uint64, err error) {
+	var parts = strings.Split(field, ",")
+	var from, to, step, n int
+	var rng []string
+	var i int
+
+	for i = range parts {
+		from, to, step = f.Min, f.Max, 1
+		rng = strings.SplitN(parts[i], "/", 2)
+		if len(rng) == 2 {
+			if step, err = strconv.Atoi(rng[1]); err != nil || step <= 0 {
+				err = fmt.Errorf("Invalid cron step %q", parts[i])
+				return
+			}
+		}
+		switch rng[0] {
+		case "*", "?":
+		default:
+			rng = strings.SplitN(rng[0], "-", 2)
+			if from, err = f.Value(rng[0]); err != nil {
+				return
+			}
+			switch {
+			case len(rng) == 2:
+				if to, err = f.Value(rng[1]); err != nil {
+					return
+				}
+			case step == 1:
+				to = from
+			}
+		}
+		if from > to {
+			err = fmt.Errorf("Invalid cron range %q", parts[i])
+			return
+		}
+		for n = from; n <= to; n += step {
+			ret |= 1 << uint(n)
+		}
+	}
+
+	return
+}
+
+// Value Разбор значения поля cron выражения
+func (f cronField) Value(s string) (ret int, err error) {
+	var ok bool
+
+	if ret, ok = f.Names[strings.ToLower(s)]; ok {
+		return
+	}
+	if ret, err = strconv.Atoi(s); err != nil || ret < f.Min || ret > f.Max {
+		err = fmt.Errorf("Invalid cron value %q, expected %d-%d", s, f.Min, f.Max)
+	}
+	return
+}
+
+// Next Время следующего запуска строго после указанного времени
+// Время перебирается вперёд по абсолютному времени, поэтому переход на летнее и зимнее время не останавливает поиск:
+// время попадающее в пропущенный час не наступает, повторяющийся час проверяется в обоих смещениях часового пояса
+func (cs *cronSchedule) Next(t time.Time) (ret time.Time) {
+	var loc = t.Location()
+	var limit = t.Year() + 5
+
+	if cs.Location != nil {
+		t = t.In(cs.Location)
+	}
+	// Отсчёт с начала следующей секунды
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	for t.Year() <= limit {
+		switch {
+		case cs.Month&(1<<uint(t.Month())) == 0:
+			t = cs.Advance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()))
+		case !cs.DayMatch(t):
+			t = cs.Advance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location()))
+		case cs.Hour&(1<<uint(t.Hour())) == 0:
+			t = cs.NextHour(t)
+		case cs.Minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute - time.Duration(t.Second())*time.Second)
+		case cs.Second&(1<<uint(t.Second())) == 0:
+			t = t.Add(time.Second)
+		default:
+			ret = t.In(loc)
+			return
+		}
+	}
+
+	return
+}
+
+// Advance Переход к началу следующего месяца или дня
+// Полночь попадающая в пропущенный при переходе на летнее время час time.Date может перенести назад,
+// в этом случае поиск продолжается со следующего часа
+func (cs *cronSchedule) Advance(t time.Time, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return cs.NextHour(t)
+}
+
+// NextHour Начало следующего часа по абсолютному времени
+func (cs *cronSchedule) NextHour(t time.Time) time.Time {
+	return t.Add(time.Hour - time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second)
+}
+
+// DayMatch Проверка дня по полям дня месяца и дня недели
+// Если оба поля ограничены, достаточно совпадения любого из них, как в классическом cron
+func (cs *cronSchedule) DayMatch(t time.Time) bool {
+	var dom = cs.Dom&(1<<uint(t.Day())) != 0
+	var dow = cs.Dow&(1<<uint(t.Weekday())) != 0
+
+	if cs.DomAny || cs.DowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next Время следующего запуска строго после указанного времени
+func (es everySchedule) Next(t time.Time) time.Time { return t.Add(es.Interval) }
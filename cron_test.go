@@ -0,0 +1,77 @@
+package tasker
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseCron Проверка времени следующего запуска для cron выражений
+func TestParseCron(t *testing.T) {
+	var sch Schedule
+	var err error
+	var from = time.Date(2024, time.January, 31, 10, 17, 30, 0, time.UTC)
+	var tests = []struct {
+		Spec string
+		Next time.Time
+	}{
+		{"*/5 * * * *", time.Date(2024, time.January, 31, 10, 20, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, time.February, 1, 2, 0, 0, 0, time.UTC)},
+		{"30 * * * * *", time.Date(2024, time.January, 31, 10, 18, 30, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2024, time.February, 4, 9, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{"15,45 10-12/2 * * *", time.Date(2024, time.January, 31, 10, 45, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(time.Second * 90)},
+		{"CRON_TZ=Etc/GMT-3 0 14 * * *", time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		if sch, err = ParseCron(test.Spec); err != nil {
+			t.Fatalf("Parse %q error: %v", test.Spec, err)
+		}
+		if next := sch.Next(from); !next.Equal(test.Next) {
+			t.Fatalf("Spec %q: expected %s, got %s", test.Spec, test.Next, next)
+		}
+	}
+	for _, spec := range []string{"", "* * * *", "61 * * * *", "* * * * * * *", "@never", "@every -1s", "5-1 * * * *", "*/0 * * * *"} {
+		if _, err = ParseCron(spec); err == nil {
+			t.Fatalf("Invalid spec %q parsed without error", spec)
+		}
+	}
+}
+
+// TestParseCronDST Переход на летнее и зимнее время не останавливает поиск и не возвращает время раньше указанного
+func TestParseCronDST(t *testing.T) {
+	var sch Schedule
+	var err error
+	var tests = []struct {
+		Spec string
+		From time.Time
+		Next time.Time
+	}{
+		// America/New_York: 2024-03-10 02:00 EST -> 03:00 EDT, 2024-11-03 02:00 EDT -> 01:00 EST
+		{"CRON_TZ=America/New_York 0 5 * * *", time.Date(2024, time.March, 10, 6, 0, 0, 0, time.UTC), time.Date(2024, time.March, 10, 9, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=America/New_York 0 5 * * *", time.Date(2024, time.March, 9, 17, 0, 0, 0, time.UTC), time.Date(2024, time.March, 10, 9, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=America/New_York 30 2 * * *", time.Date(2024, time.March, 10, 5, 0, 0, 0, time.UTC), time.Date(2024, time.March, 11, 6, 30, 0, 0, time.UTC)},
+		{"CRON_TZ=America/New_York 45 1 * * *", time.Date(2024, time.November, 3, 5, 30, 0, 0, time.UTC), time.Date(2024, time.November, 3, 5, 45, 0, 0, time.UTC)},
+		{"CRON_TZ=America/New_York 45 1 * * *", time.Date(2024, time.November, 3, 6, 30, 0, 0, time.UTC), time.Date(2024, time.November, 3, 6, 45, 0, 0, time.UTC)},
+		{"CRON_TZ=America/New_York */15 * * * *", time.Date(2024, time.November, 3, 5, 50, 0, 0, time.UTC), time.Date(2024, time.November, 3, 6, 0, 0, 0, time.UTC)},
+		// Europe/Berlin: 2024-03-31 02:00 CET -> 03:00 CEST, 2024-10-27 03:00 CEST -> 02:00 CET
+		{"CRON_TZ=Europe/Berlin 0 5 * * *", time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, time.March, 31, 3, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=Europe/Berlin 30 2 * * *", time.Date(2024, time.March, 30, 11, 0, 0, 0, time.UTC), time.Date(2024, time.April, 1, 0, 30, 0, 0, time.UTC)},
+		{"CRON_TZ=Europe/Berlin 45 2 * * *", time.Date(2024, time.October, 27, 1, 30, 0, 0, time.UTC), time.Date(2024, time.October, 27, 1, 45, 0, 0, time.UTC)},
+		{"CRON_TZ=Europe/Berlin 0 * * * *", time.Date(2024, time.October, 27, 0, 30, 0, 0, time.UTC), time.Date(2024, time.October, 27, 1, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		if sch, err = ParseCron(test.Spec); err != nil {
+			t.Fatalf("Parse %q error: %v", test.Spec, err)
+		}
+		if next := sch.Next(test.From); !next.Equal(test.Next) || !next.After(test.From) {
+			t.Fatalf("Spec %q from %s: expected %s, got %s", test.Spec, test.From, test.Next, next)
+		}
+	}
+}
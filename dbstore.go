@@ -0,0 +1,462 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dbMagic                = "TASKDB01" // Сигнатура файла базы задач
+	dbHeaderSize           = 16         // Размер заголовка файла: сигнатура и зарезервированные байты
+	dbRecordMagic   uint32 = 0x54524543 // Сигнатура записи задачи
+	dbRecordHeader         = 8          // Размер заголовка записи: сигнатура и вместимость версии записи
+	dbVersionHeader        = 20         // Размер заголовка версии записи: контрольная сумма, номер, флаги и длина
+	dbMinCapacity          = 256        // Минимальная вместимость версии записи
+	dbDeleted       uint32 = 1          // Флаг версии записи удалённой задачи
+)
+
+// DBStore Хранилище задач в файле базы данных с запросами по состоянию задач
+// Каждая задача занимает в файле одну запись из двух версий, изменение задачи записывается на место более старой
+// версии, поэтому оборванная при аварийном завершении процесса запись не портит последнюю сохранённую версию.
+// При открытии читается таблица записей, а не журнал изменений, записи завершённых задач используются повторно
+type DBStore interface {
+	Store
+	Query(StoreQuery) ([]StoredTask, error) // Записи задач удовлетворяющих условиям запроса в порядке возрастания идентификаторов
+	Compact() error                         // Удаление из файла записей завершённых задач
+}
+
+// StoreQuery Условия выбора записей задач, пустые условия не ограничивают выбор
+type StoreQuery struct {
+	InWork    *bool         // Задачи переданные работнику (true) или ожидающие запуска (false)
+	Prelude   *bool         // Задачи обработанные (true) или не обработанные (false) функцией BootstrapFunc
+	OlderThan time.Duration // Задачи добавленные раньше указанного времени назад
+	Failed    bool          // Задачи с неудачными попытками выполнения
+	Error     string        // Задачи, ошибка последней неудачной попытки которых содержит строку
+	Limit     int           // Максимальное количество записей, 0 - не ограничено
+}
+
+// dbStore Реализация хранилища задач в файле базы данных
+type dbStore struct {
+	Path  string             // Путь к файлу базы
+	File  *os.File           // Открытый файл базы
+	Size  int64              // Размер файла базы
+	Seq   uint64             // Номер последней записанной версии записи
+	Index map[TaskID]*dbSlot // Записи не завершённых задач
+	Free  []*dbSlot          // Записи завершённых задач, доступные для повторного использования
+	sync.Mutex
+}
+
+// dbSlot Запись задачи в файле базы
+type dbSlot struct {
+	Offset   int64      // Смещение записи в файле
+	Capacity int        // Вместимость версии записи
+	Half     int        // Номер последней версии записи, 0 или 1
+	Seq      uint64     // Номер последней версии записи
+	Task     StoredTask // Запись задачи без объекта задачи
+}
+
+// OpenDBStore Открытие или создание хранилища задач в файле базы данных
+func OpenDBStore(path string) (ret DBStore, err error) {
+	var db = &dbStore{Path: path}
+
+	if err = db.Open(); err != nil {
+		return
+	}
+	ret = db
+	return
+}
+
+// Open Открытие файла базы и чтение таблицы записей
+// Оборванная при добавлении запись в конце файла отбрасывается, из повторяющихся записей одной задачи
+// остаётся запись с большим номером версии, остальные помечаются удалёнными
+func (db *dbStore) Open() (err error) {
+	var header = make([]byte, dbHeaderSize)
+	var info os.FileInfo
+	var slot, old *dbSlot
+	var offset, size int64
+	var ok bool
+
+	if db.File, err = os.OpenFile(db.Path, os.O_RDWR|os.O_CREATE, 0o600); err != nil {
+		err = fmt.Errorf("Open task database error: %s", err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = db.File.Close()
+			db.File = nil
+		}
+	}()
+	if info, err = db.File.Stat(); err != nil {
+		err = fmt.Errorf("Task database stat error: %s", err)
+		return
+	}
+	db.Index, db.Free, db.Seq, db.Size = make(map[TaskID]*dbSlot), nil, 0, info.Size()
+	if db.Size < dbHeaderSize {
+		copy(header, dbMagic)
+		if err = db.Write(header, 0); err != nil {
+			return
+		}
+		db.Size = dbHeaderSize
+		return
+	}
+	if _, err = db.File.ReadAt(header, 0); err != nil || !bytes.Equal(header[:len(dbMagic)], []byte(dbMagic)) {
+		err = fmt.Errorf("Not a task database: %s", db.Path)
+		return
+	}
+	for offset = dbHeaderSize; offset < db.Size; offset += size {
+		if slot, size = db.Scan(offset); slot == nil {
+			// Запись добавлявшаяся в момент аварийного завершения процесса
+			if err = db.File.Truncate(offset); err != nil {
+				err = fmt.Errorf("Truncate task database error: %s", err)
+				return
+			}
+			db.Size = offset
+			break
+		}
+		if db.Seq = max(db.Seq, slot.Seq); slot.Seq == 0 || slot.Task.ID == 0 {
+			db.Free = append(db.Free, slot)
+			continue
+		}
+		if old, ok = db.Index[slot.Task.ID]; ok {
+			if old.Seq > slot.Seq {
+				old, slot = slot, old
+			}
+			if err = db.Delete(old); err != nil {
+				return
+			}
+		}
+		db.Index[slot.Task.ID] = slot
+	}
+
+	return
+}
+
+// Scan Чтение записи по смещению, nil - запись оборвана или повреждена
+// Запись без целых версий и запись удалённой задачи возвращаются с нулевым номером задачи
+func (db *dbStore) Scan(offset int64) (ret *dbSlot, size int64) {
+	var header = make([]byte, dbRecordHeader)
+	var flags uint32
+	var payload []byte
+	var seq uint64
+	var h int
+
+	if _, err := db.File.ReadAt(header, offset); err != nil || binary.LittleEndian.Uint32(header[0:4]) != dbRecordMagic {
+		return
+	}
+	ret = &dbSlot{Offset: offset, Capacity: int(binary.LittleEndian.Uint32(header[4:8])), Half: 1}
+	if size = ret.Size(); ret.Capacity < dbMinCapacity || offset+size > db.Size {
+		ret = nil
+		return
+	}
+	for h = 0; h < 2; h++ {
+		if seq, flags, payload = db.ReadHalf(ret, h); seq <= ret.Seq {
+			continue
+		}
+		ret.Half, ret.Seq, ret.Task = h, seq, StoredTask{}
+		if flags&dbDeleted == 0 && json.Unmarshal(payload, &ret.Task) == nil {
+			ret.Task.Body = nil
+		}
+	}
+	return
+}
+
+// Size Размер записи в файле
+func (slot *dbSlot) Size() int64 { return int64(dbRecordHeader + 2*(dbVersionHeader+slot.Capacity)) }
+
+// HalfOffset Смещение версии записи в файле
+func (slot *dbSlot) HalfOffset(h int) int64 {
+	return slot.Offset + dbRecordHeader + int64(h*(dbVersionHeader+slot.Capacity))
+}
+
+// ReadHalf Чтение версии записи, нулевой номер - версия не записана или повреждена
+func (db *dbStore) ReadHalf(slot *dbSlot, h int) (seq uint64, flags uint32, payload []byte) {
+	var buf = make([]byte, dbVersionHeader+slot.Capacity)
+	var length uint32
+
+	if _, err := db.File.ReadAt(buf, slot.HalfOffset(h)); err != nil && err != io.EOF {
+		return
+	}
+	if length = binary.LittleEndian.Uint32(buf[16:20]); int(length) > slot.Capacity {
+		return
+	}
+	if crc32.ChecksumIEEE(buf[4:dbVersionHeader+length]) != binary.LittleEndian.Uint32(buf[0:4]) {
+		return
+	}
+	seq, flags = binary.LittleEndian.Uint64(buf[4:12]), binary.LittleEndian.Uint32(buf[12:16])
+	payload = buf[dbVersionHeader : dbVersionHeader+length]
+	return
+}
+
+// Version Версия записи с заголовком
+func (db *dbStore) Version(flags uint32, payload []byte) (ret []byte) {
+	db.Seq++
+	ret = make([]byte, dbVersionHeader, dbVersionHeader+len(payload))
+	binary.LittleEndian.PutUint64(ret[4:12], db.Seq)
+	binary.LittleEndian.PutUint32(ret[12:16], flags)
+	binary.LittleEndian.PutUint32(ret[16:20], uint32(len(payload)))
+	ret = append(ret, payload...)
+	binary.LittleEndian.PutUint32(ret[0:4], crc32.ChecksumIEEE(ret[4:]))
+	return
+}
+
+// Write Запись в файл базы со сбросом на диск
+func (db *dbStore) Write(data []byte, offset int64) (err error) {
+	if db.File == nil {
+		err = fmt.Errorf("Task database is closed")
+		return
+	}
+	if _, err = db.File.WriteAt(data, offset); err != nil {
+		err = fmt.Errorf("Write task database error: %s", err)
+		return
+	}
+	if err = db.File.Sync(); err != nil {
+		err = fmt.Errorf("Sync task database error: %s", err)
+	}
+	return
+}
+
+// Update Запись новой версии на место более старой версии записи
+func (db *dbStore) Update(slot *dbSlot, flags uint32, payload []byte) (err error) {
+	var h = 1 - slot.Half
+
+	if err = db.Write(db.Version(flags, payload), slot.HalfOffset(h)); err != nil {
+		return
+	}
+	slot.Half, slot.Seq = h, db.Seq
+	return
+}
+
+// Delete Пометка записи удалённой и перенос записи в список доступных для повторного использования
+func (db *dbStore) Delete(slot *dbSlot) (err error) {
+	if err = db.Update(slot, dbDeleted, nil); err != nil {
+		return
+	}
+	slot.Task = StoredTask{}
+	db.Free = append(db.Free, slot)
+	return
+}
+
+// Allocate Выбор записи для задачи: запись завершённой задачи подходящей вместимости или новая запись в конце файла
+func (db *dbStore) Allocate(payload []byte) (ret *dbSlot, err error) {
+	var buf []byte
+
+	for i := range db.Free {
+		if db.Free[i].Capacity >= len(payload) {
+			ret = db.Free[i]
+			db.Free = append(db.Free[:i], db.Free[i+1:]...)
+			err = db.Update(ret, 0, payload)
+			return
+		}
+	}
+	// Вместимость с запасом на рост записи при изменении состояния задачи и тексте ошибки
+	ret = &dbSlot{Offset: db.Size, Capacity: max(dbMinCapacity, (len(payload)*3/2+63)/64*64)}
+	buf = make([]byte, ret.Size())
+	binary.LittleEndian.PutUint32(buf[0:4], dbRecordMagic)
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(ret.Capacity))
+	copy(buf[dbRecordHeader:], db.Version(0, payload))
+	if err = db.Write(buf, ret.Offset); err != nil {
+		return
+	}
+	db.Size += ret.Size()
+	ret.Half, ret.Seq = 0, db.Seq
+	return
+}
+
+// Put Сохранение записи задачи, запись не помещающаяся на место прежней записи переносится
+// Прежняя запись помечается удалённой после сохранения новой записи
+func (db *dbStore) Put(st StoredTask) (err error) {
+	var payload []byte
+	var slot, old *dbSlot
+	var ok bool
+
+	if payload, err = json.Marshal(st); err != nil {
+		err = fmt.Errorf("Encode task record error: %s", err)
+		return
+	}
+	if old, ok = db.Index[st.ID]; ok && len(payload) <= old.Capacity {
+		if err = db.Update(old, 0, payload); err != nil {
+			return
+		}
+		old.Task, old.Task.Body = st, nil
+		return
+	}
+	if slot, err = db.Allocate(payload); err != nil {
+		return
+	}
+	slot.Task, slot.Task.Body = st, nil
+	db.Index[st.ID] = slot
+	if ok {
+		err = db.Delete(old)
+	}
+	return
+}
+
+// Get Чтение записи задачи вместе с объектом задачи
+func (db *dbStore) Get(slot *dbSlot) (ret StoredTask, err error) {
+	var payload []byte
+
+	if _, _, payload = db.ReadHalf(slot, slot.Half); payload == nil {
+		err = fmt.Errorf("Task %d record is corrupted", slot.Task.ID)
+		return
+	}
+	if err = json.Unmarshal(payload, &ret); err != nil {
+		err = fmt.Errorf("Decode task %d record error: %s", slot.Task.ID, err)
+	}
+	return
+}
+
+// Enqueue Добавление или замена записи задачи
+func (db *dbStore) Enqueue(st StoredTask) error {
+	db.Lock()
+	defer db.Unlock()
+	st.InWork = false
+	return db.Put(st)
+}
+
+// Lease Отметка о передаче задачи работнику
+func (db *dbStore) Lease(id TaskID) (err error) {
+	var st StoredTask
+	var slot *dbSlot
+	var ok bool
+
+	db.Lock()
+	defer db.Unlock()
+	if slot, ok = db.Index[id]; !ok {
+		return
+	}
+	if st, err = db.Get(slot); err != nil {
+		return
+	}
+	st.InWork, st.Prelude, st.Updated = true, true, time.Now()
+	err = db.Put(st)
+	return
+}
+
+// Ack Удаление записи завершённой задачи
+func (db *dbStore) Ack(id TaskID) (err error) {
+	var slot *dbSlot
+	var ok bool
+
+	db.Lock()
+	defer db.Unlock()
+	if slot, ok = db.Index[id]; !ok {
+		return
+	}
+	if err = db.Delete(slot); err == nil {
+		delete(db.Index, id)
+	}
+	return
+}
+
+// Nack Возврат задачи в очередь
+func (db *dbStore) Nack(st StoredTask) error { return db.Enqueue(st) }
+
+// List Все записи задач в порядке возрастания идентификаторов
+func (db *dbStore) List() ([]StoredTask, error) { return db.Query(StoreQuery{}) }
+
+// Query Записи задач удовлетворяющих условиям запроса в порядке возрастания идентификаторов
+func (db *dbStore) Query(q StoreQuery) ([]StoredTask, error) {
+	db.Lock()
+	defer db.Unlock()
+	return db.Select(q)
+}
+
+// Select Выбор записей задач по условиям запроса, вызывается под блокировкой хранилища
+func (db *dbStore) Select(q StoreQuery) (ret []StoredTask, err error) {
+	var slots []*dbSlot
+	var st StoredTask
+	var before time.Time
+
+	if q.OlderThan > 0 {
+		before = time.Now().Add(-q.OlderThan)
+	}
+	for _, slot := range db.Index {
+		switch {
+		case q.InWork != nil && slot.Task.InWork != *q.InWork:
+		case q.Prelude != nil && slot.Task.Prelude != *q.Prelude:
+		case !before.IsZero() && !slot.Task.Created.Before(before):
+		case q.Failed && slot.Task.CountError == 0:
+		case q.Error != "" && !strings.Contains(slot.Task.Error, q.Error):
+		default:
+			slots = append(slots, slot)
+		}
+	}
+	ret = make([]StoredTask, 0, len(slots))
+	for _, slot := range slots {
+		ret = append(ret, slot.Task)
+	}
+	sortStored(ret)
+	if q.Limit > 0 && len(ret) > q.Limit {
+		ret = ret[:q.Limit]
+	}
+	for i := range ret {
+		if st, err = db.Get(db.Index[ret[i].ID]); err != nil {
+			return
+		}
+		ret[i] = st
+	}
+	return
+}
+
+// Compact Запись не завершённых задач в новый файл базы и атомарная замена файла
+func (db *dbStore) Compact() (err error) {
+	var items []StoredTask
+	var tmp = &dbStore{Path: db.Path + ".tmp"}
+
+	db.Lock()
+	defer db.Unlock()
+	if db.File == nil {
+		err = fmt.Errorf("Task database is closed")
+		return
+	}
+	if items, err = db.Select(StoreQuery{}); err != nil {
+		return
+	}
+	_ = os.Remove(tmp.Path)
+	if err = tmp.Open(); err != nil {
+		return
+	}
+	for i := range items {
+		if err = tmp.Put(items[i]); err != nil {
+			_ = tmp.File.Close()
+			return
+		}
+	}
+	if err = tmp.File.Close(); err != nil {
+		err = fmt.Errorf("Close task database error: %s", err)
+		return
+	}
+	if err = os.Rename(tmp.Path, db.Path); err != nil {
+		err = fmt.Errorf("Replace task database error: %s", err)
+		return
+	}
+	syncDir(filepath.Dir(db.Path))
+	_ = db.File.Close()
+	err = db.Open()
+	return
+}
+
+// Close Закрытие файла базы
+func (db *dbStore) Close() (err error) {
+	db.Lock()
+	defer db.Unlock()
+	if db.File == nil {
+		return
+	}
+	if err = db.File.Close(); err != nil {
+		err = fmt.Errorf("Close task database error: %s", err)
+	}
+	db.File = nil
+	return
+}
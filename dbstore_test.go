@@ -0,0 +1,170 @@
+package tasker
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dbSummary Краткое описание состояния задач хранилища для сравнения
+func dbSummary(t *testing.T, path string) string {
+	var store DBStore
+	var items []StoredTask
+	var ret []string
+	var err error
+
+	if store, err = OpenDBStore(path); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer store.Close()
+	if items, err = store.List(); err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	for i := range items {
+		ret = append(ret, fmt.Sprintf("%d:%s:%t:%d", items[i].ID, items[i].Body, items[i].InWork, items[i].CountError))
+	}
+	return strings.Join(ret, ",")
+}
+
+// TestDBStore Семантика хранилища задач в файле базы данных и сохранение состояния после повторного открытия
+func TestDBStore(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "tasks.db")
+	var store DBStore
+	var err error
+
+	if store, err = OpenDBStore(path); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	testStore(t, store)
+	store.Close()
+	if s := dbSummary(t, path); s != "2:\x02:true:0,3:\x03:false:1" {
+		t.Fatalf("Unexpected state after reopen: %q", s)
+	}
+}
+
+// TestDBStoreQuery Выбор задач по состоянию, возрасту и ошибке, сжатие файла базы
+func TestDBStoreQuery(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "tasks.db")
+	var store DBStore
+	var items []StoredTask
+	var yes, no = true, false
+	var old = time.Now().Add(-time.Hour)
+	var info os.FileInfo
+	var size int64
+	var err error
+
+	if store, err = OpenDBStore(path); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer func() { store.Close() }()
+	for i := 1; i <= 100; i++ {
+		store.Enqueue(StoredTask{ID: TaskID(i), Body: []byte("body"), Created: old.Add(time.Duration(i) * time.Minute)})
+	}
+	store.Lease(1)
+	store.Lease(2)
+	store.Nack(StoredTask{ID: 3, Body: []byte("body"), Created: old, CountError: 2, Error: "connection refused"})
+	store.Nack(StoredTask{ID: 4, Body: []byte("body"), Created: old, CountError: 1, Error: "timeout"})
+	if items, _ = store.Query(StoreQuery{InWork: &yes}); len(items) != 2 || items[1].ID != 2 || string(items[1].Body) != "body" {
+		t.Fatalf("Unexpected in work tasks: %+v", items)
+	}
+	if items, _ = store.Query(StoreQuery{InWork: &no, Prelude: &no, Limit: 5}); len(items) != 5 || items[0].ID != 3 {
+		t.Fatalf("Unexpected queued tasks: %+v", items)
+	}
+	if items, _ = store.Query(StoreQuery{Failed: true, Error: "refused"}); len(items) != 1 || items[0].ID != 3 {
+		t.Fatalf("Unexpected failed tasks: %+v", items)
+	}
+	if items, _ = store.Query(StoreQuery{OlderThan: time.Minute*50 + time.Second*30}); len(items) != 9 {
+		t.Fatalf("Unexpected number of old tasks: %d", len(items))
+	}
+	for i := 5; i <= 100; i++ {
+		store.Ack(TaskID(i))
+	}
+	info, _ = os.Stat(path)
+	if size = info.Size(); store.Compact() != nil {
+		t.Fatalf("Compact error")
+	}
+	if info, _ = os.Stat(path); info.Size()*10 > size {
+		t.Fatalf("Database not compacted: %d -> %d", size, info.Size())
+	}
+	store.Enqueue(StoredTask{ID: 5, Body: []byte("new")})
+	store.Close()
+	if s := dbSummary(t, path); s != "1:body:true:0,2:body:true:0,3:body:false:2,4:body:false:1,5:new:false:0" {
+		t.Fatalf("Unexpected state after compaction: %q", s)
+	}
+}
+
+// TestDBStoreCrash Аварийное завершение процесса на каждом байте записи каждой операции
+// Состояние базы после открытия должно совпадать с состоянием до операции или после операции
+func TestDBStoreCrash(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "tasks.db")
+	var crash = filepath.Join(dir, "crash.db")
+	var store DBStore
+	var before, after, written []byte
+	var stateBefore, stateAfter, state string
+	var err error
+	var ops = []func(){
+		func() { store.Enqueue(StoredTask{ID: 1, Body: []byte("a")}) },
+		func() { store.Enqueue(StoredTask{ID: 2, Body: []byte("b")}) },
+		func() { store.Enqueue(StoredTask{ID: 3, Body: []byte("c")}) },
+		func() { store.Lease(2) },
+		func() { store.Ack(1) },
+		func() { store.Enqueue(StoredTask{ID: 4, Body: []byte("d")}) },
+		// Запись не помещается на прежнее место и переносится в конец файла
+		func() {
+			store.Nack(StoredTask{ID: 3, Body: []byte("c"), CountError: 1, Error: strings.Repeat("e", 512)})
+		},
+		func() { store.Ack(2) },
+	}
+
+	if store, err = OpenDBStore(path); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer func() { store.Close() }()
+	for n, op := range ops {
+		before, _ = os.ReadFile(path)
+		stateBefore = dbSummary(t, path)
+		op()
+		after, _ = os.ReadFile(path)
+		stateAfter = dbSummary(t, path)
+		if stateBefore == stateAfter {
+			t.Fatalf("Operation %d changed nothing", n)
+		}
+		// Порядок записи операции: сначала добавление в конец файла, затем изменение на месте
+		for k := 0; k <= len(after); k++ {
+			if written = crashed(before, after, k); written == nil {
+				break
+			}
+			os.WriteFile(crash, written, 0o600)
+			if state = dbSummary(t, crash); state != stateBefore && state != stateAfter {
+				t.Fatalf("Operation %d, crash at byte %d: state %q, expected %q or %q", n, k, state, stateBefore, stateAfter)
+			}
+		}
+	}
+}
+
+// crashed Содержимое файла при аварийном завершении после записи k байт операции
+// Сначала записывается добавленная в конец файла часть, затем изменённая на месте часть, nil - k больше размера записи
+func crashed(before, after []byte, k int) (ret []byte) {
+	var appended = after[len(before):]
+	var from, to = 0, len(before)
+
+	for from < to && before[from] == after[from] {
+		from++
+	}
+	for to > from && before[to-1] == after[to-1] {
+		to--
+	}
+	if k > len(appended)+to-from {
+		return
+	}
+	ret = append(append([]byte(nil), before...), appended[:min(k, len(appended))]...)
+	if k > len(appended) {
+		copy(ret[from:], after[from:from+k-len(appended)])
+	}
+	return bytes.Clone(ret)
+}
@@ -0,0 +1,117 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"container/list"
+	"time"
+)
+
+// DeadLetters Список задач исчерпавших попытки выполнения
+func (tsk *implementation[T]) DeadLetters() (ret []TypedDeadLetter[T]) {
+	var elm *list.Element
+
+	tsk.Lock()
+	defer tsk.Unlock()
+	for elm = tsk.DeadTasks.Front(); elm != nil; elm = elm.Next() {
+		ret = append(ret, elm.Value.(*task[T]).DeadLetter())
+	}
+	return
+}
+
+// DrainDeadLetters Извлечение и удаление всех задач из списка исчерпавших попытки выполнения
+func (tsk *implementation[T]) DrainDeadLetters() (ret []TypedDeadLetter[T]) {
+	var elm *list.Element
+
+	tsk.Lock()
+	defer tsk.Unlock()
+	for elm = tsk.DeadTasks.Front(); elm != nil; elm = elm.Next() {
+		ret = append(ret, elm.Value.(*task[T]).DeadLetter())
+	}
+	tsk.DeadTasks.Init()
+	return
+}
+
+// Requeue Возврат задачи исчерпавшей попытки выполнения в очередь выполнения
+// Счётчик ошибок задачи сбрасывается, история попыток сохраняется
+func (tsk *implementation[T]) Requeue(id TaskID) (err error) {
+	var elm *list.Element
+
+	tsk.Lock()
+	defer tsk.Unlock()
+	for elm = tsk.DeadTasks.Front(); elm != nil; elm = elm.Next() {
+		if elm.Value.(*task[T]).ID != id {
+			continue
+		}
+		err = tsk.RequeueTask(elm)
+		return
+	}
+	err = ErrTaskNotFound
+	return
+}
+
+// RequeueAll Возврат всех задач исчерпавших попытки выполнения в очередь выполнения
+// Задачи возвращаются в порядке попадания в список, поэтому задачи пропущенные из за ошибки зависимости
+// возвращаются после задач от которых зависят. Возвращает количество возвращённых в очередь задач
+func (tsk *implementation[T]) RequeueAll() (ret int) {
+	var elm, next *list.Element
+
+	tsk.Lock()
+	defer tsk.Unlock()
+	for elm = tsk.DeadTasks.Front(); elm != nil; elm = next {
+		next = elm.Next()
+		if tsk.RequeueTask(elm) == nil {
+			ret++
+		}
+	}
+	return
+}
+
+// RequeueTask Перемещение задачи из списка исчерпавших попытки выполнения в очередь выполнения
+// Задача не может быть возвращена, пока задача от которой она зависит не возвращена в очередь
+func (tsk *implementation[T]) RequeueTask(elm *list.Element) (err error) {
+	var item = elm.Value.(*task[T])
+
+	if err = tsk.Link(item); err != nil {
+		return
+	}
+	if err = tsk.Save(item); err != nil {
+		return
+	}
+	tsk.DeadTasks.Remove(elm)
+	tsk.Forget(item.ID)
+	item.Lock()
+	item.InWork = false
+	item.CountError = 0
+	item.Delay = 0
+	item.NotBefore = time.Time{}
+	item.Failed = time.Time{}
+	item.Err = nil
+	item.Completed = make(chan struct{})
+	item.Status, item.Cancelled, item.Outcome = StatusQueued, false, Result{}
+	item.Unlock()
+	tsk.Pending[item.ID] = item
+	item.Element = tsk.Tasks.PushBack(item)
+	tsk.Claim(item)
+	tsk.Serialize(item)
+	if item.Prelude {
+		tsk.Enqueue(item)
+	}
+	tsk.Signal()
+
+	return
+}
+
+// DeadLetter Описание задачи исчерпавшей попытки выполнения
+func (t *task[T]) DeadLetter() TypedDeadLetter[T] {
+	t.Lock()
+	defer t.Unlock()
+	return TypedDeadLetter[T]{
+		ID:       t.ID,
+		Body:     t.Body,
+		Attempts: append([]Attempt(nil), t.History...),
+		Error:    t.Err,
+		Created:  t.Created,
+		Failed:   t.Failed,
+	}
+}
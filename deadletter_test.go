@@ -0,0 +1,71 @@
+package tasker
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestDeadLetters Задачи исчерпавшие попытки выполнения попадают в список и могут быть возвращены в очередь
+func TestDeadLetters(t *testing.T) {
+	var tasks Tasker
+	var fixed bool
+	var dead []DeadLetter
+
+	tasks = NewTasker().
+		Concurrent(2).
+		RetryIfError(2).
+		Worker(func(in interface{}) error {
+			if in.(string) == "bad" && !fixed {
+				return fmt.Errorf("Broken %s", in)
+			}
+			return nil
+		})
+	_ = tasks.AddTasks([]interface{}{"good", "bad", "good", "bad"})
+	tasks.Run().Wait()
+
+	if dead = tasks.DeadLetters(); len(dead) != 2 {
+		t.Fatalf("Unexpected number of dead letters: %d", len(dead))
+	}
+	for i := range dead {
+		if dead[i].Body.(string) != "bad" || len(dead[i].Attempts) != 2 || dead[i].Failed.IsZero() {
+			t.Fatalf("Invalid dead letter: %+v", dead[i])
+		}
+		if dead[i].Attempts[1].Error == nil || dead[i].Attempts[1].Error.Error() != "Broken bad" {
+			t.Fatalf("Invalid attempt error: %v", dead[i].Attempts[1].Error)
+		}
+	}
+	if err := tasks.Requeue(TaskID(100)); err != ErrTaskNotFound {
+		t.Fatalf("Requeue unknown task, unexpected error: %v", err)
+	}
+	if err := tasks.Requeue(dead[0].ID); err != nil {
+		t.Fatalf("Requeue error: %v", err)
+	}
+	if tasks.GetTasksNumber() != 1 || len(tasks.DeadLetters()) != 1 {
+		t.Fatalf("Task was not requeued")
+	}
+	fixed = true
+	if n := tasks.RequeueAll(); n != 1 {
+		t.Fatalf("Unexpected number of requeued tasks: %d", n)
+	}
+	tasks.Run().Wait()
+	if tasks.GetTasksNumber() != 0 || len(tasks.DrainDeadLetters()) != 0 {
+		t.Fatalf("Requeued tasks was not done")
+	}
+}
+
+// TestDrainDeadLetters Извлечение задач из списка исчерпавших попытки выполнения
+func TestDrainDeadLetters(t *testing.T) {
+	var tasks Tasker
+
+	tasks = NewTasker().
+		Concurrent(1).
+		Worker(func(in interface{}) error { return fmt.Errorf("Failed") })
+	_ = tasks.AddTasks([]interface{}{1, 2, 3})
+	tasks.Run().Wait()
+	if dead := tasks.DrainDeadLetters(); len(dead) != 3 {
+		t.Fatalf("Unexpected number of dead letters: %d", len(dead))
+	}
+	if len(tasks.DeadLetters()) != 0 {
+		t.Fatalf("Dead letters not drained")
+	}
+}
@@ -0,0 +1,152 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"container/list"
+	"time"
+)
+
+// DedupMode Поведение при добавлении задачи с ключом уже ожидающей или выполняющейся задачи
+type DedupMode int
+
+const (
+	// DedupReject Не добавлять новую задачу и вернуть ошибку ErrDuplicateTask
+	DedupReject DedupMode = iota
+
+	// DedupReplace Удалить ожидающую запуска задачу с тем же ключом и добавить новую задачу
+	// Удалённая задача завершается с ошибкой ErrTaskReplaced, задачи зависевшие от неё ожидают выполнения новой задачи
+	DedupReplace
+
+	// DedupMerge Не добавлять новую задачу, объект новой задачи объединяется с объектом ожидающей задачи
+	// функцией установленной Merge(), дескриптор ожидающей задачи возвращается вместо дескриптора новой задачи
+	DedupMerge
+)
+
+// Запомненный ключ успешно выполненной задачи
+type dedupKey struct {
+	Key     string    // Ключ задачи
+	Expires time.Time // Время окончания хранения ключа
+}
+
+// WithKey Опция задачи, ключ идемпотентности задачи
+// Задача с ключом уже ожидающей или выполняющейся задачи обрабатывается согласно режиму установленному Dedup()
+func WithKey(key string) TaskOption {
+	return func(t *taskParams) { t.Key = key }
+}
+
+// Dedup Режим обработки задач с ключом уже ожидающей или выполняющейся задачи, по умолчанию DedupReject
+// Выполняющаяся задача не может быть заменена или объединена, новая задача с её ключом не добавляется.
+// Значение ttl > 0 включает хранение ключей успешно выполненных задач в течение ttl,
+// задачи с запомненным ключом не добавляются с ошибкой ErrDuplicateTask
+func (tsk *implementation[T]) Dedup(mode DedupMode, ttl time.Duration) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	if tsk.DedupMode, tsk.DedupTTL = mode, max(ttl, 0); tsk.DedupTTL == 0 {
+		tsk.Seen, tsk.SeenOrder = nil, nil
+	}
+	return tsk
+}
+
+// Merge Функция объединения объекта ожидающей задачи queued с объектом добавляемой задачи added в режиме DedupMerge
+// По умолчанию nil - объект ожидающей задачи не изменяется
+func (tsk *implementation[T]) Merge(fn func(queued, added T) T) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	tsk.MergeFn = fn
+	return tsk
+}
+
+// Deduplicate Обработка добавляемой задачи с ключом уже известной задачи, вызывается под блокировкой таскера
+// ret != nil - задача не добавляется, ret содержит дескриптор задачи с которой объединена новая задача,
+// replaced != nil - ожидающая задача, которая удаляется после того как новая задача будет принята
+func (tsk *implementation[T]) Deduplicate(item *task[T]) (ret Handle, replaced *task[T], err error) {
+	var old *task[T]
+
+	if item.Key == "" {
+		return
+	}
+	if tsk.Remembered(item.Key, time.Now()) {
+		err = ErrDuplicateTask
+		return
+	}
+	if old = tsk.Keys[item.Key]; old == nil {
+		return
+	}
+	switch {
+	case tsk.DedupMode == DedupReject || old.InWork:
+		err = ErrDuplicateTask
+	case tsk.DedupMode == DedupReplace:
+		replaced = old
+	case tsk.DedupMode == DedupMerge:
+		if tsk.MergeFn != nil {
+			old.Body, old.Encoded = tsk.SafeMerge(old.Body, item.Body), nil
+			if err = tsk.Save(old); err != nil {
+				return
+			}
+		}
+		ret = &handle[T]{Task: old, Parent: tsk}
+	default:
+		err = ErrDuplicateTask
+	}
+
+	return
+}
+
+// SafeMerge Безопасный вызов внешней функции объединения объектов задач, при панике объект ожидающей задачи не изменяется
+func (tsk *implementation[T]) SafeMerge(queued, added T) (ret T) {
+	defer func() {
+		if e := recover(); e != nil {
+			ret = queued
+		}
+	}()
+	ret = tsk.MergeFn(queued, added)
+	return
+}
+
+// Claim Учёт ключа добавленной задачи
+// Ключ занятый другой задачей не изменяется, так может быть при возврате в очередь задачи исчерпавшей попытки выполнения
+func (tsk *implementation[T]) Claim(item *task[T]) {
+	if item.Key == "" {
+		return
+	}
+	if tsk.Keys == nil {
+		tsk.Keys = make(map[string]*task[T])
+	}
+	if tsk.Keys[item.Key] == nil {
+		tsk.Keys[item.Key] = item
+	}
+}
+
+// Unclaim Освобождение ключа завершённой задачи, ключ успешно выполненной задачи запоминается на время DedupTTL
+func (tsk *implementation[T]) Unclaim(item *task[T], err error) {
+	if item.Key == "" || tsk.Keys[item.Key] != item {
+		return
+	}
+	delete(tsk.Keys, item.Key)
+	if err != nil || tsk.DedupTTL == 0 {
+		return
+	}
+	if tsk.Seen == nil {
+		tsk.Seen, tsk.SeenOrder = make(map[string]*list.Element), list.New()
+	}
+	if elm, ok := tsk.Seen[item.Key]; ok {
+		tsk.SeenOrder.Remove(elm)
+	}
+	tsk.Seen[item.Key] = tsk.SeenOrder.PushBack(dedupKey{Key: item.Key, Expires: time.Now().Add(tsk.DedupTTL)})
+}
+
+// Remembered Ключ успешно выполненной задачи ещё хранится, ключи с истёкшим временем хранения удаляются
+func (tsk *implementation[T]) Remembered(key string, now time.Time) (ok bool) {
+	var elm *list.Element
+
+	if tsk.Seen == nil {
+		return
+	}
+	for elm = tsk.SeenOrder.Front(); elm != nil && !now.Before(elm.Value.(dedupKey).Expires); elm = tsk.SeenOrder.Front() {
+		delete(tsk.Seen, elm.Value.(dedupKey).Key)
+		tsk.SeenOrder.Remove(elm)
+	}
+	_, ok = tsk.Seen[key]
+	return
+}
@@ -0,0 +1,125 @@
+package tasker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestDedupReject Задача с ключом ожидающей или выполняющейся задачи не добавляется
+func TestDedupReject(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1)
+	var release = make(chan struct{})
+	var done []int
+
+	tasks.Worker(func(in int) error {
+		if in == 1 {
+			<-release
+		}
+		done = append(done, in)
+		return nil
+	})
+	if tasks.AddTask(1, WithKey("a")) != nil || tasks.AddTask(2, WithKey("b")) != nil || tasks.AddTask(3) != nil {
+		t.Fatalf("Tasks not added")
+	}
+	if err := tasks.AddTask(4, WithKey("b")); err != ErrDuplicateTask {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tasks.Run()
+	waitStats(t, tasks, func(s Stats) bool { return s.InWork == 1 })
+	if err := tasks.AddTask(5, WithKey("a")); err != ErrDuplicateTask {
+		t.Fatalf("Unexpected error for task in work: %v", err)
+	}
+	close(release)
+	tasks.Wait()
+	// Ключи выполненных задач без времени хранения освобождаются
+	if err := tasks.AddTask(6, WithKey("a")); err != nil {
+		t.Fatalf("Key of done task not released: %v", err)
+	}
+	tasks.Run().Wait()
+	if len(done) != 4 || done[3] != 6 {
+		t.Fatalf("Unexpected done tasks: %v", done)
+	}
+}
+
+// TestDedupReplaceMerge Замена ожидающей задачи и объединение объектов задач с одинаковым ключом
+func TestDedupReplaceMerge(t *testing.T) {
+	var tasks = NewTyped[[]string]().Concurrent(1)
+	var replaced []TaskID
+	var done [][]string
+	var h1, h2, hd Handle
+	var err error
+
+	tasks.Worker(func(in []string) error { done = append(done, in); return nil })
+	tasks.OnComplete(func(rsl TypedResult[[]string]) {
+		if rsl.Error == ErrTaskReplaced {
+			replaced = append(replaced, rsl.ID)
+		}
+	})
+	tasks.Dedup(DedupReplace, 0)
+	h1, _ = tasks.Submit([]string{"old"}, WithKey("a"))
+	hd, _ = tasks.Submit([]string{"dep"}, DependsOn(h1.ID()))
+	// Новая задача не может зависеть от задачи, которая зависит от заменяемой задачи
+	if _, err = tasks.Submit([]string{"cycle"}, WithKey("a"), DependsOn(hd.ID())); err != ErrDependencyCycle {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if h2, err = tasks.Submit([]string{"new"}, WithKey("a")); err != nil || h2.ID() == h1.ID() {
+		t.Fatalf("Task not replaced: %v", err)
+	}
+	select {
+	case <-h1.Done():
+	default:
+		t.Fatalf("Replaced task not done")
+	}
+	// Не принятая новая задача не удаляет ожидающую задачу
+	if _, err = tasks.Submit([]string{"bad"}, WithKey("a"), DependsOn(1000)); err != ErrUnknownDependency {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	select {
+	case <-h2.Done():
+		t.Fatalf("Queued task replaced by rejected task")
+	default:
+	}
+	tasks.Dedup(DedupMerge, 0).Merge(func(queued, added []string) []string { return append(queued, added...) })
+	if h1, err = tasks.Submit([]string{"more"}, WithKey("a")); err != nil || h1.ID() != h2.ID() {
+		t.Fatalf("Task not merged: %v", err)
+	}
+	tasks.Run().Wait()
+	if len(done) != 2 || len(done[0]) != 2 || done[0][1] != "more" || len(replaced) != 1 {
+		t.Fatalf("Unexpected done tasks %v, replaced %v", done, replaced)
+	}
+	// Задача зависевшая от заменённой задачи выполняется после новой задачи
+	if rsl, _ := hd.Wait(context.Background()); rsl.Error != nil || done[1][0] != "dep" {
+		t.Fatalf("Dependent of replaced task not executed: %v, %v", rsl.Error, done)
+	}
+}
+
+// TestDedupTTL Ключи успешно выполненных задач хранятся указанное время
+func TestDedupTTL(t *testing.T) {
+	var tasks = NewTyped[int]().Dedup(DedupReject, time.Millisecond*50)
+	var done int
+
+	tasks.Worker(func(in int) error {
+		if done++; in < 0 {
+			return ErrTimeout
+		}
+		return nil
+	})
+	tasks.AddTask(1, WithKey("a"))
+	tasks.AddTask(-1, WithKey("failed"))
+	tasks.Run().Wait()
+	if err := tasks.AddTask(2, WithKey("a")); err != ErrDuplicateTask {
+		t.Fatalf("Replay of done task accepted: %v", err)
+	}
+	if err := tasks.AddTask(-2, WithKey("failed")); err != nil {
+		t.Fatalf("Key of failed task remembered: %v", err)
+	}
+	time.Sleep(time.Millisecond * 60)
+	if err := tasks.AddTask(3, WithKey("a")); err != nil {
+		t.Fatalf("Key not expired: %v", err)
+	}
+	tasks.Run().Wait()
+	if done != 4 {
+		t.Fatalf("Unexpected number of done tasks: %d", done)
+	}
+}
@@ -0,0 +1,201 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"container/list"
+	"time"
+)
+
+// Количество хранимых итогов завершённых задач по умолчанию
+const outcomeLimit = 10000
+
+// Итог завершённой задачи
+type outcome struct {
+	ID        TaskID    // Идентификатор задачи
+	Succeeded bool      // =true - задача выполнена успешно
+	Finished  time.Time // Время завершения задачи
+}
+
+// DependsOn Опция задачи, задача запускается только после успешного выполнения всех указанных задач
+// Если одна из указанных задач завершилась ошибкой, задача пропускается с ошибкой ErrDependencyFailed
+func DependsOn(ids ...TaskID) TaskOption {
+	return func(t *taskParams) { t.Deps = append(t.Deps, ids...) }
+}
+
+// Outcomes Количество и время хранения итогов завершённых задач, по умолчанию 10000 итогов без ограничения времени
+// Итог нужен только для задач добавляемых с зависимостью от уже завершённой задачи, зависимость от задачи,
+// итог которой больше не хранится, считается неизвестной и задача не добавляется с ошибкой ErrUnknownDependency.
+// Значение 0 снимает соответствующее ограничение
+func (tsk *implementation[T]) Outcomes(limit int, ttl time.Duration) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	tsk.OutcomeLimit, tsk.OutcomeTTL = max(limit, 0), max(ttl, 0)
+	tsk.Prune(time.Now())
+	return tsk
+}
+
+// Remember Сохранение итога завершённой задачи, самые старые итоги сверх ограничения удаляются
+func (tsk *implementation[T]) Remember(id TaskID, succeeded bool) {
+	var now = time.Now()
+	var rec = outcome{ID: id, Succeeded: succeeded, Finished: now}
+
+	tsk.Forget(id)
+	tsk.Succeeded[id] = tsk.OutcomeOrder.PushBack(rec)
+	tsk.Prune(now)
+}
+
+// Forget Удаление итога задачи
+func (tsk *implementation[T]) Forget(id TaskID) {
+	if elm, ok := tsk.Succeeded[id]; ok {
+		tsk.OutcomeOrder.Remove(elm)
+		delete(tsk.Succeeded, id)
+	}
+}
+
+// Prune Удаление итогов с истёкшим временем хранения и самых старых итогов сверх ограничения количества
+func (tsk *implementation[T]) Prune(now time.Time) {
+	var elm *list.Element
+	var rec outcome
+
+	for elm = tsk.OutcomeOrder.Front(); elm != nil; elm = tsk.OutcomeOrder.Front() {
+		rec = elm.Value.(outcome)
+		if (tsk.OutcomeLimit == 0 || tsk.OutcomeOrder.Len() <= tsk.OutcomeLimit) && (tsk.OutcomeTTL == 0 || now.Sub(rec.Finished) < tsk.OutcomeTTL) {
+			return
+		}
+		tsk.Forget(rec.ID)
+	}
+}
+
+// Outcome Итог завершённой задачи, =false ok - итог задачи неизвестен
+func (tsk *implementation[T]) Outcome(id TaskID, now time.Time) (succeeded bool, ok bool) {
+	var elm *list.Element
+
+	tsk.Prune(now)
+	if elm, ok = tsk.Succeeded[id]; ok {
+		succeeded = elm.Value.(outcome).Succeeded
+	}
+	return
+}
+
+// Link Связывание задачи с задачами от которых она зависит
+// Вызывается при добавлении задачи и при возврате задачи в очередь выполнения
+func (tsk *implementation[T]) Link(item *task[T]) (err error) {
+	var dep *task[T]
+	var ok, succeeded bool
+	var i int
+
+	for i = range item.Deps {
+		if item.Deps[i] == item.ID {
+			err = ErrDependencyCycle
+			return
+		}
+		if _, ok = tsk.Pending[item.Deps[i]]; ok {
+			continue
+		}
+		if succeeded, ok = tsk.Outcome(item.Deps[i], time.Now()); !ok {
+			err = ErrUnknownDependency
+			return
+		}
+		if !succeeded {
+			err = ErrDependencyFailed
+			return
+		}
+	}
+	if tsk.HasCycle(item) {
+		err = ErrDependencyCycle
+		return
+	}
+	item.Waiting = 0
+	for i = range item.Deps {
+		if dep = tsk.Pending[item.Deps[i]]; dep == nil {
+			continue
+		}
+		dep.Dependents = append(dep.Dependents, item)
+		item.Waiting++
+	}
+
+	return
+}
+
+// Adopt Передача задаче item задач зависящих от задачи from, зависимость от from заменяется зависимостью от item
+// Используется при замене ожидающей задачи в режиме DedupReplace, количество ожидаемых зависимостей не изменяется
+func (tsk *implementation[T]) Adopt(item *task[T], from *task[T]) {
+	var i int
+
+	for _, dependent := range from.Dependents {
+		for i = range dependent.Deps {
+			if dependent.Deps[i] == from.ID {
+				dependent.Deps[i] = item.ID
+			}
+		}
+	}
+	item.Dependents = append(item.Dependents, from.Dependents...)
+	from.Dependents = nil
+}
+
+// HasCycle Проверка, что задача не зависит сама от себя через цепочку ожидающих выполнения задач
+func (tsk *implementation[T]) HasCycle(item *task[T]) bool {
+	var visited = make(map[TaskID]bool)
+	var stack = append([]TaskID(nil), item.Deps...)
+	var id TaskID
+	var dep *task[T]
+
+	for len(stack) > 0 {
+		id, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if id == item.ID {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		if dep = tsk.Pending[id]; dep != nil {
+			stack = append(stack, dep.Deps...)
+		}
+	}
+
+	return false
+}
+
+// Resolve Обработка завершения задачи для зависящих от неё задач
+// При успешном выполнении зависимые задачи, дождавшиеся всех зависимостей, помещаются в очередь,
+// при ошибке зависимые задачи пропускаются каскадно
+func (tsk *implementation[T]) Resolve(item *task[T], err error) {
+	var dependents = item.Dependents
+	var i int
+
+	delete(tsk.Pending, item.ID)
+	tsk.Remember(item.ID, err == nil)
+	tsk.Advance(item)
+	item.Dependents = nil
+	for i = range dependents {
+		if _, ok := tsk.Pending[dependents[i].ID]; !ok {
+			continue
+		}
+		if err != nil {
+			tsk.Skip(dependents[i])
+			continue
+		}
+		if dependents[i].Waiting--; dependents[i].Waiting == 0 && dependents[i].Prelude {
+			tsk.Enqueue(dependents[i])
+		}
+	}
+}
+
+// Skip Пропуск задачи из за ошибки задачи от которой она зависит
+// Задача перемещается в список исчерпавших попытки выполнения и может быть возвращена в очередь
+func (tsk *implementation[T]) Skip(item *task[T]) {
+	var r = &result[T]{Task: item, Error: ErrDependencyFailed, Finished: time.Now(), WorkerID: -1}
+
+	if item.Element != nil {
+		tsk.Tasks.Remove(item.Element)
+		item.Element = nil
+	}
+	tsk.Dequeue(item)
+	item.Failed = r.Finished
+	item.Err = r.Error
+	tsk.DeadTasks.PushBack(item)
+	tsk.Resolve(item, r.Error)
+	tsk.Complete(r)
+}
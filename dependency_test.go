@@ -0,0 +1,139 @@
+package tasker
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDependencies Задача запускается только после успешного выполнения зависимостей
+func TestDependencies(t *testing.T) {
+	var tasks Tasker
+	var mu sync.Mutex
+	var done = make(map[string]int)
+	var step int
+	var download, parse, index Handle
+	var err error
+
+	tasks = NewTasker().
+		Concurrent(4).
+		Worker(func(in interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			step++
+			done[in.(string)] = step
+			return nil
+		})
+	if download, err = tasks.Submit("download"); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	if parse, err = tasks.Submit("parse", DependsOn(download.ID())); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	if index, err = tasks.Submit("index", DependsOn(parse.ID(), download.ID())); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	if _, err = tasks.Submit("unknown", DependsOn(index.ID()+100)); err != ErrUnknownDependency {
+		t.Fatalf("Unknown dependency, unexpected error: %v", err)
+	}
+	tasks.Run().Wait()
+	if done["download"] != 1 || done["parse"] != 2 || done["index"] != 3 {
+		t.Fatalf("Unexpected execution order: %v", done)
+	}
+	if _, err = tasks.Submit("reindex", DependsOn(index.ID())); err != nil {
+		t.Fatalf("Dependency on succeeded task, unexpected error: %v", err)
+	}
+}
+
+// TestDependencyFailed Ошибка задачи каскадно пропускает зависящие от неё задачи
+func TestDependencyFailed(t *testing.T) {
+	var tasks Tasker
+	var mu sync.Mutex
+	var fixed bool
+	var calls []string
+	var download, parse Handle
+	var dead []DeadLetter
+	var err error
+
+	tasks = NewTasker().
+		Concurrent(2).
+		Worker(func(in interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, in.(string))
+			if in.(string) == "download" && !fixed {
+				return fmt.Errorf("Download failed")
+			}
+			return nil
+		})
+	download, _ = tasks.Submit("download")
+	parse, _ = tasks.Submit("parse", DependsOn(download.ID()))
+	_, _ = tasks.Submit("index", DependsOn(parse.ID()))
+	_, _ = tasks.Submit("other")
+	tasks.Run().Wait()
+	if len(calls) != 2 {
+		t.Fatalf("Dependent tasks was executed: %v", calls)
+	}
+	if dead = tasks.DeadLetters(); len(dead) != 3 {
+		t.Fatalf("Unexpected number of dead letters: %d", len(dead))
+	}
+	if dead[1].Error != ErrDependencyFailed || dead[2].Error != ErrDependencyFailed {
+		t.Fatalf("Dependent tasks not skipped: %v, %v", dead[1].Error, dead[2].Error)
+	}
+	if _, err = tasks.Submit("report", DependsOn(download.ID())); err != ErrDependencyFailed {
+		t.Fatalf("Dependency on failed task, unexpected error: %v", err)
+	}
+	if err = tasks.Requeue(parse.ID()); err != ErrDependencyFailed {
+		t.Fatalf("Requeue before dependency, unexpected error: %v", err)
+	}
+
+	fixed, calls = true, calls[:0]
+	if n := tasks.RequeueAll(); n != 3 {
+		t.Fatalf("Unexpected number of requeued tasks: %d", n)
+	}
+	tasks.Run().Wait()
+	if fmt.Sprint(calls) != "[download parse index]" {
+		t.Fatalf("Unexpected execution after requeue: %v", calls)
+	}
+}
+
+// TestDependencyOutcomes Итоги завершённых задач хранятся ограниченное количество и время
+func TestDependencyOutcomes(t *testing.T) {
+	var tasks = NewTyped[int]().Outcomes(2, 0)
+	var handles []Handle
+	var h Handle
+	var err error
+
+	tasks.Worker(func(in int) error {
+		if in == 2 {
+			return fmt.Errorf("failed %d", in)
+		}
+		return nil
+	})
+	for i := 1; i <= 3; i++ {
+		if h, err = tasks.Submit(i); err != nil {
+			t.Fatalf("Submit error: %v", err)
+		}
+		handles = append(handles, h)
+	}
+	tasks.Run().Wait()
+	if _, err = tasks.Submit(4, DependsOn(handles[0].ID())); err != ErrUnknownDependency {
+		t.Fatalf("Pruned outcome, unexpected error: %v", err)
+	}
+	if _, err = tasks.Submit(5, DependsOn(handles[1].ID())); err != ErrDependencyFailed {
+		t.Fatalf("Failed dependency, unexpected error: %v", err)
+	}
+	if _, err = tasks.Submit(6, DependsOn(handles[2].ID())); err != nil {
+		t.Fatalf("Done dependency, unexpected error: %v", err)
+	}
+	tasks.Outcomes(0, time.Millisecond*20).Run().Wait()
+	if h, err = tasks.Submit(7); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	tasks.Run().Wait()
+	time.Sleep(time.Millisecond * 30)
+	if _, err = tasks.Submit(8, DependsOn(h.ID())); err != ErrUnknownDependency {
+		t.Fatalf("Expired outcome, unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package tasker
+
+import "errors"
+
+var (
+	// ErrTimeout Время выполнения задачи истекло
+	ErrTimeout = errors.New("Task execution timeout")
+
+	// ErrTaskNotFound Задача с указанным идентификатором не найдена
+	ErrTaskNotFound = errors.New("Task not found")
+
+	// ErrUnknownDependency Задача зависит от неизвестной задачи
+	ErrUnknownDependency = errors.New("Unknown task dependency")
+
+	// ErrDependencyCycle Зависимости задачи образуют цикл
+	ErrDependencyCycle = errors.New("Task dependency cycle")
+
+	// ErrDependencyFailed Задача пропущена, так как задача от которой она зависит завершилась ошибкой
+	ErrDependencyFailed = errors.New("Task dependency failed")
+
+	// ErrQueueFull Очередь задач заполнена, задача не добавлена
+	ErrQueueFull = errors.New("Task queue is full")
+
+	// ErrDuplicateTask Задача с тем же ключом ожидает запуска, выполняется или недавно выполнена, задача не добавлена
+	ErrDuplicateTask = errors.New("Duplicate task key")
+
+	// ErrTaskReplaced Задача удалена из очереди, так как добавлена задача с тем же ключом
+	ErrTaskReplaced = errors.New("Task replaced by task with the same key")
+
+	// ErrUnregisteredType Тип объекта задачи не зарегистрирован в Codec
+	ErrUnregisteredType = errors.New("Task body type is not registered")
+
+	// ErrTaskDropped Задача удалена из очереди из-за переполнения очереди
+	ErrTaskDropped = errors.New("Task dropped due to queue overflow")
+
+	// ErrTaskCancelled Задача отменена вызовом Handle.Cancel()
+	ErrTaskCancelled = errors.New("Task cancelled")
+)
@@ -0,0 +1,3 @@
+module github.com/sniperkit/tasker
+
+go 1.21
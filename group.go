@@ -0,0 +1,133 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"container/heap"
+)
+
+// ConcurrentBy Ограничение количества одновременно выполняющихся задач каждой группы, группа задачи определяется функцией key
+// Общее ограничение Concurrent() продолжает действовать. Задачи групп, исчерпавших ограничение, откладываются до завершения
+// задачи той же группы, а работникам передаются задачи других групп. Значение n <= 0 или key = nil снимает ограничение
+func (tsk *implementation[T]) ConcurrentBy(key func(T) string, n int) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	if tsk.GroupKey, tsk.GroupLimit = nil, 0; key != nil && n > 0 {
+		tsk.GroupKey, tsk.GroupLimit = key, n
+	}
+	if tsk.Running == nil {
+		tsk.Running = make(map[string]int)
+	}
+	tsk.UnparkAll()
+	tsk.Signal()
+	return tsk
+}
+
+// Saturated Группа задачи исчерпала ограничение одновременно выполняющихся задач
+// Задача исчерпавшей ограничение группы откладывается до завершения задачи той же группы
+func (tsk *implementation[T]) Saturated(item *task[T]) bool {
+	if tsk.GroupKey == nil {
+		return false
+	}
+	if item.Group = tsk.SafeGroup(item.Body); tsk.Running[item.Group] < tsk.GroupLimit {
+		return false
+	}
+	tsk.Park(item)
+	return true
+}
+
+// SafeGroup Безопасный вызов внешней функции получения группы, при панике используется пустая группа
+func (tsk *implementation[T]) SafeGroup(body T) (ret string) {
+	defer func() {
+		if e := recover(); e != nil {
+			ret = ""
+		}
+	}()
+	ret = tsk.GroupKey(body)
+	return
+}
+
+// Occupy Учёт запущенной задачи в её группе
+func (tsk *implementation[T]) Occupy(item *task[T]) {
+	if tsk.GroupKey == nil {
+		return
+	}
+	item.Grouped = true
+	tsk.Running[item.Group]++
+}
+
+// Release Учёт завершения задачи в её группе, одна отложенная задача группы возвращается в очередь
+func (tsk *implementation[T]) Release(item *task[T]) {
+	if !item.Grouped {
+		return
+	}
+	item.Grouped = false
+	if tsk.Running[item.Group]--; tsk.Running[item.Group] <= 0 {
+		delete(tsk.Running, item.Group)
+	}
+	tsk.Unpark(item.Group)
+}
+
+// Park Откладывание задачи группы, исчерпавшей ограничение, время помещения задачи в очередь сохраняется
+func (tsk *implementation[T]) Park(item *task[T]) {
+	var q *queue[T]
+	var ok bool
+
+	if tsk.Parked == nil {
+		tsk.Parked = make(map[string]*queue[T])
+	}
+	if q, ok = tsk.Parked[item.Group]; !ok {
+		q = &queue[T]{Aging: tsk.Ready.Aging}
+		tsk.Parked[item.Group] = q
+	}
+	item.Parked = true
+	heap.Push(q, item)
+}
+
+// Unpark Возврат в очередь готовых к запуску задач отложенной задачи группы с наибольшим приоритетом
+func (tsk *implementation[T]) Unpark(group string) {
+	var q *queue[T]
+	var item *task[T]
+	var ok bool
+
+	if q, ok = tsk.Parked[group]; !ok {
+		return
+	}
+	if item = q.Next(); item != nil {
+		item.Parked = false
+		heap.Push(tsk.Ready, item)
+	}
+	if q.Len() == 0 {
+		delete(tsk.Parked, group)
+	}
+}
+
+// UnparkAll Возврат в очередь готовых к запуску всех отложенных задач
+func (tsk *implementation[T]) UnparkAll() {
+	var q *queue[T]
+	var i int
+
+	for _, q = range tsk.Parked {
+		for i = range q.Items {
+			q.Items[i].Parked = false
+			heap.Push(tsk.Ready, q.Items[i])
+		}
+		q.Items = nil
+	}
+	tsk.Parked = nil
+}
+
+// Dequeue Удаление задачи из очереди готовых к запуску задач, из расписания и из отложенных задач групп
+func (tsk *implementation[T]) Dequeue(item *task[T]) {
+	tsk.Ready.Remove(item)
+	tsk.Scheduled.Remove(item)
+	item.Held = false
+	if item.Parked && tsk.Parked[item.Group] != nil {
+		tsk.Parked[item.Group].Remove(item)
+		item.Parked = false
+	}
+	if item.Limited && tsk.KeyLimit != nil && tsk.KeyLimit.Waiting[item.LimitKey] != nil {
+		tsk.KeyLimit.Waiting[item.LimitKey].Remove(item)
+		item.Limited = false
+	}
+}
@@ -0,0 +1,93 @@
+package tasker
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentBy Ограничение одновременно выполняющихся задач каждой группы
+func TestConcurrentBy(t *testing.T) {
+	var tasks = NewTyped[string]().Concurrent(4)
+	var mu sync.Mutex
+	var running = make(map[string]int)
+	var peak = make(map[string]int)
+	var total, peakTotal int
+	var order []string
+
+	tasks.ConcurrentBy(func(in string) string { return strings.Split(in, ":")[0] }, 2)
+	tasks.Worker(func(in string) error {
+		var group = strings.Split(in, ":")[0]
+
+		mu.Lock()
+		running[group]++
+		total++
+		peak[group] = max(peak[group], running[group])
+		peakTotal = max(peakTotal, total)
+		order = append(order, group)
+		mu.Unlock()
+		time.Sleep(time.Millisecond * 5)
+		mu.Lock()
+		running[group]--
+		total--
+		mu.Unlock()
+		return nil
+	})
+	for i := 0; i < 20; i++ {
+		tasks.AddTask("big:task")
+	}
+	tasks.AddTask("small:task")
+	tasks.AddTask("small:task")
+	tasks.Run().Wait()
+	if len(order) != 22 || tasks.GetTasksNumber() != 0 {
+		t.Fatalf("Unexpected number of executed tasks: %d", len(order))
+	}
+	if peak["big"] > 2 || peak["small"] > 2 || peakTotal > 4 {
+		t.Fatalf("Concurrency limit exceeded: %v, total %d", peak, peakTotal)
+	}
+	// Задачи малой группы не ждут выполнения всех задач большой группы
+	for i := range order[:4] {
+		if order[i] == "small" {
+			return
+		}
+	}
+	t.Fatalf("Small group starved: %v", order)
+}
+
+// TestConcurrentByRelease Отложенные задачи групп учитываются как готовые и возвращаются в очередь при снятии ограничения
+func TestConcurrentByRelease(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(4)
+	var release = make(chan struct{})
+	var done int
+	var mu sync.Mutex
+
+	tasks.ConcurrentBy(func(int) string { return "one" }, 1)
+	tasks.Worker(func(int) error {
+		<-release
+		mu.Lock()
+		done++
+		mu.Unlock()
+		return nil
+	})
+	for i := 0; i < 5; i++ {
+		tasks.AddTask(i)
+	}
+	tasks.Run()
+	for deadline := time.Now().Add(time.Second); tasks.Stats().InWork != 1; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Task not started: %+v", tasks.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// Отложенные задачи группы ожидают завершения выполняющейся задачи группы
+	if stats := tasks.Stats(); stats.Blocked != 4 || stats.Ready != 0 {
+		t.Fatalf("Parked tasks not counted as blocked: %+v", stats)
+	}
+	tasks.ConcurrentBy(nil, 0)
+	close(release)
+	tasks.Wait()
+	if done != 5 {
+		t.Fatalf("Unexpected number of done tasks: %d", done)
+	}
+}
@@ -0,0 +1,160 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"context"
+)
+
+// TaskStatus Состояние задачи
+type TaskStatus int
+
+const (
+	// StatusQueued Задача ожидает запуска
+	StatusQueued TaskStatus = iota
+
+	// StatusBootstrapping Задача обрабатывается функцией BootstrapFunc
+	StatusBootstrapping
+
+	// StatusRunning Задача выполняется
+	StatusRunning
+
+	// StatusRetrying Попытка выполнения задачи завершилась ошибкой, задача ожидает повторного запуска
+	StatusRetrying
+
+	// StatusSucceeded Задача выполнена успешно
+	StatusSucceeded
+
+	// StatusFailed Задача завершилась ошибкой, была удалена из очереди или пропущена
+	StatusFailed
+
+	// StatusCancelled Задача отменена вызовом Handle.Cancel()
+	StatusCancelled
+)
+
+// String Название состояния задачи
+func (s TaskStatus) String() string {
+	switch s {
+	case StatusQueued:
+		return "queued"
+	case StatusBootstrapping:
+		return "bootstrapping"
+	case StatusRunning:
+		return "running"
+	case StatusRetrying:
+		return "retrying"
+	case StatusSucceeded:
+		return "succeeded"
+	case StatusFailed:
+		return "failed"
+	case StatusCancelled:
+		return "cancelled"
+	}
+	return "unknown"
+}
+
+// Handle Дескриптор задачи добавленной в таскер
+type Handle interface {
+	ID() TaskID                               // Идентификатор задачи
+	Status() TaskStatus                       // Текущее состояние задачи
+	Attempts() int                            // Количество начатых попыток выполнения задачи
+	Cancel() bool                             // Отмена задачи, =false - задача уже завершена
+	Done() <-chan struct{}                    // Канал закрывается по завершении задачи, в том числе при удалении задачи из очереди
+	Wait(ctx context.Context) (Result, error) // Ожидание завершения задачи и получение итогового результата
+}
+
+// handle Реализация дескриптора задачи
+type handle[T any] struct {
+	Task   *task[T]           // Задача
+	Parent *implementation[T] // Таскер которому принадлежит задача
+}
+
+// ID Идентификатор задачи
+func (h *handle[T]) ID() TaskID { return h.Task.ID }
+
+// Status Текущее состояние задачи
+func (h *handle[T]) Status() TaskStatus {
+	h.Task.Lock()
+	defer h.Task.Unlock()
+	return h.Task.Status
+}
+
+// Attempts Количество начатых попыток выполнения задачи
+func (h *handle[T]) Attempts() int {
+	h.Task.Lock()
+	defer h.Task.Unlock()
+	return h.Task.Attempts
+}
+
+// Cancel Отмена задачи
+// Ожидающая запуска задача удаляется из очереди, у выполняющейся задачи отменяется контекст и она не повторяется.
+// Задача завершается с ошибкой ErrTaskCancelled, задачи зависящие от отменённой задачи пропускаются.
+// Выполняющаяся задача, успешно завершившаяся несмотря на отмену контекста, считается выполненной успешно
+func (h *handle[T]) Cancel() (ret bool) {
+	var tsk, item = h.Parent, h.Task
+
+	tsk.Lock()
+	if tsk.Pending[item.ID] != item {
+		tsk.Unlock()
+		return
+	}
+	item.Lock()
+	item.Cancelled, ret = true, true
+	if item.InWork {
+		if item.Abort != nil {
+			item.Abort()
+		}
+		item.Unlock()
+		tsk.Unlock()
+		return
+	}
+	item.Unlock()
+	tsk.Discard(item, ErrTaskCancelled)
+	tsk.Unlock()
+	tsk.Deliver()
+
+	return
+}
+
+// Done Канал закрывается по завершении задачи
+// Если задача исчерпавшая попытки выполнения возвращена в очередь, канал заменяется новым
+func (h *handle[T]) Done() <-chan struct{} {
+	h.Task.Lock()
+	defer h.Task.Unlock()
+	return h.Task.Completed
+}
+
+// Wait Ожидание завершения задачи и получение итогового результата
+// Возвращается ошибка контекста, если контекст завершился раньше задачи, иначе итоговая ошибка задачи
+func (h *handle[T]) Wait(ctx context.Context) (ret Result, err error) {
+	select {
+	case <-h.Done():
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	}
+	h.Task.Lock()
+	ret, err = h.Task.Outcome, h.Task.Outcome.Error
+	h.Task.Unlock()
+	return
+}
+
+// Drain Завершение задачи удалённой из очереди функцией Clean(), итоговый результат задачи не передаётся
+func (t *task[T]) Drain() {
+	t.Lock()
+	t.Status = StatusCancelled
+	t.Outcome = Result{ID: t.ID, Body: t.Body, Error: ErrTaskCancelled, Attempts: t.Attempts, Started: t.Started}
+	t.Unlock()
+	t.Finish()
+}
+
+// Finish Сигнал завершения задачи для ожидающих её дескрипторов
+func (t *task[T]) Finish() {
+	t.Lock()
+	defer t.Unlock()
+	select {
+	case <-t.Completed:
+	default:
+		close(t.Completed)
+	}
+}
@@ -0,0 +1,152 @@
+package tasker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitStatus Ожидание состояния задачи
+func waitStatus(t *testing.T, h Handle, status TaskStatus) {
+	var deadline = time.Now().Add(5 * time.Second)
+
+	for h.Status() != status {
+		if time.Now().After(deadline) {
+			t.Fatalf("Task %d status %s, expected %s", h.ID(), h.Status(), status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestHandleStatus Состояния задачи от добавления до успешного выполнения после повтора
+func TestHandleStatus(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1).RetryIfError(2).Backoff(ConstantBackoff(100 * time.Millisecond))
+	var release = make(chan struct{})
+	var h Handle
+	var rsl Result
+	var err error
+
+	tasks.WorkerValue(func(_ context.Context, in int) (interface{}, error) {
+		select {
+		case <-release:
+			return "ok", nil
+		default:
+		}
+		return nil, errors.New("first attempt")
+	})
+	if h, err = tasks.Submit(1); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	if h.Status() != StatusQueued || h.Attempts() != 0 {
+		t.Fatalf("Unexpected status %s, attempts %d", h.Status(), h.Attempts())
+	}
+	tasks.Run()
+	waitStatus(t, h, StatusRetrying)
+	close(release)
+	if rsl, err = h.Wait(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if h.Status() != StatusSucceeded || h.Attempts() != 2 || rsl.Attempts != 2 || rsl.Value != "ok" {
+		t.Fatalf("Unexpected status %s, attempts %d, result %+v", h.Status(), h.Attempts(), rsl)
+	}
+	if h.Cancel() {
+		t.Fatalf("Finished task cancelled")
+	}
+	tasks.Wait()
+}
+
+// TestHandleCancel Отмена ожидающей и выполняющейся задачи, зависящие задачи пропускаются
+func TestHandleCancel(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1).RetryIfError(3)
+	var started = make(chan struct{}, 1)
+	var running, queued, dependent Handle
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var done []int
+	var err error
+
+	tasks.WorkerCtx(func(ctx context.Context, in int) error {
+		done = append(done, in)
+		if in == 1 {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	if running, err = tasks.Submit(1); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	if queued, err = tasks.Submit(2); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	if dependent, err = tasks.Submit(3, DependsOn(queued.ID())); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	tasks.Run()
+	<-started
+	waitStatus(t, running, StatusRunning)
+	// Ожидание с истёкшим контекстом не дожидается задачи
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	if _, err = running.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Unexpected wait error: %v", err)
+	}
+	cancel()
+	if !queued.Cancel() {
+		t.Fatalf("Queued task not cancelled")
+	}
+	if _, err = queued.Wait(context.Background()); err != ErrTaskCancelled || queued.Status() != StatusCancelled {
+		t.Fatalf("Unexpected queued task error %v, status %s", err, queued.Status())
+	}
+	if _, err = dependent.Wait(context.Background()); err != ErrDependencyFailed || dependent.Status() != StatusFailed {
+		t.Fatalf("Unexpected dependent task error %v, status %s", err, dependent.Status())
+	}
+	if !running.Cancel() {
+		t.Fatalf("Running task not cancelled")
+	}
+	if _, err = running.Wait(context.Background()); err != ErrTaskCancelled || running.Status() != StatusCancelled {
+		t.Fatalf("Unexpected running task error %v, status %s", err, running.Status())
+	}
+	tasks.Wait()
+	// Отменённая задача не повторяется и не попадает в список исчерпавших попытки выполнения
+	if running.Attempts() != 1 || len(done) != 1 || len(tasks.DeadLetters()) != 1 {
+		t.Fatalf("Unexpected attempts %d, done tasks %v, dead letters %d", running.Attempts(), done, len(tasks.DeadLetters()))
+	}
+}
+
+// TestHandleClean Очистка очереди не завершает выполняющиеся задачи
+func TestHandleClean(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1)
+	var release = make(chan struct{})
+	var running, queued Handle
+	var err error
+
+	tasks.Worker(func(in int) error {
+		if in == 1 {
+			<-release
+		}
+		return nil
+	})
+	running, _ = tasks.Submit(1)
+	queued, _ = tasks.Submit(2)
+	tasks.Run()
+	waitStatus(t, running, StatusRunning)
+	tasks.Clean()
+	if _, err = queued.Wait(context.Background()); err != ErrTaskCancelled || queued.Status() != StatusCancelled {
+		t.Fatalf("Unexpected queued task error %v, status %s", err, queued.Status())
+	}
+	select {
+	case <-running.Done():
+		t.Fatalf("Running task finished by Clean")
+	default:
+	}
+	if n := tasks.GetTasksNumber(); n != 1 || running.Status() != StatusRunning {
+		t.Fatalf("Unexpected tasks %d, running task status %s", n, running.Status())
+	}
+	close(release)
+	if _, err = running.Wait(context.Background()); err != nil || running.Status() != StatusSucceeded {
+		t.Fatalf("Unexpected running task error %v, status %s", err, running.Status())
+	}
+	tasks.Wait()
+}
@@ -0,0 +1,199 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"context"
+	"sync"
+)
+
+// Mapper is an interface of parallel map over a batch of tasks
+type Mapper[T, R any] interface {
+	// Map Выполнение задач и сбор результатов в срез в порядке входных данных
+	// Функция блокируется до завершения всех задач или до отмены контекста
+	// При отмене контекста не завершённые задачи отменяются, возвращаются собранные к этому моменту результаты
+	// и ошибка контекста, результаты не завершённых задач содержат ошибку контекста
+	Map(ctx context.Context, in []T) ([]Output[R], error)
+
+	// Stream Выполнение задач с передачей результатов в канал в порядке завершения задач
+	// Канал закрывается после получения результатов всех задач или при отмене контекста, не завершённые задачи при этом отменяются
+	Stream(ctx context.Context, in []T) (<-chan Output[R], error)
+}
+
+// MapFunc Тип функции выполняющей задачу типа T и возвращающей результат типа R
+type MapFunc[T, R any] func(context.Context, T) (R, error)
+
+// Output Результат выполнения одной задачи функцией MapFunc
+type Output[R any] struct {
+	Index    int    // Порядковый номер объекта задачи во входном срезе
+	ID       TaskID // Идентификатор задачи
+	Value    R      // Значение возвращённое последней попыткой выполнения
+	Error    error  // Ошибка последней попытки выполнения, nil если задача выполнена успешно
+	Attempts int    // Количество попыток выполнения задачи
+}
+
+// mapper is an Mapper implementation
+type mapper[T, R any] struct {
+	Tasker Typed[T] // Таскер выполняющий задачи
+}
+
+// Состояние сбора результатов одного вызова Map или Stream
+type collector[R any] struct {
+	Outputs    []Output[R]    // Результаты в порядке входных данных
+	Handles    []Handle       // Дескрипторы добавленных задач
+	Seen       []bool         // =true - результат задачи уже получен
+	Left       int            // Количество задач результат которых ещё не получен
+	Ch         chan Output[R] // Канал результатов, для Stream
+	Done       chan struct{}  // Закрывается после получения результатов всех задач
+	Closed     bool           // =true - сбор результатов завершён
+	sync.Mutex                // Безопасненько всё делаем
+}
+
+// WorkerWithResult Установка функции обрабатывающей задачи и возвращающей результат
+// Таскер используется как параллельный map над пакетом задач, настройки таскера (Concurrent, RetryIfError, Timeout и прочие) сохраняются
+func WorkerWithResult[T, R any](tsk Typed[T], fn MapFunc[T, R]) Mapper[T, R] {
+	tsk.WorkerValue(func(ctx context.Context, in T) (interface{}, error) { return fn(ctx, in) })
+	return &mapper[T, R]{Tasker: tsk}
+}
+
+// Map Выполнение задач и сбор результатов в срез в порядке входных данных
+func (mpr *mapper[T, R]) Map(ctx context.Context, in []T) (ret []Output[R], err error) {
+	var clt *collector[R]
+
+	if clt, err = mpr.Start(ctx, in, false); err != nil {
+		return
+	}
+	select {
+	case <-clt.Done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	clt.Lock()
+	clt.Closed = true
+	ret = make([]Output[R], len(clt.Outputs))
+	copy(ret, clt.Outputs)
+	for i := range ret {
+		if !clt.Seen[i] {
+			ret[i] = Output[R]{Index: i, ID: clt.Handles[i].ID(), Error: err}
+		}
+	}
+	clt.Unlock()
+	clt.Cancel()
+
+	return
+}
+
+// Stream Выполнение задач с передачей результатов в канал в порядке завершения задач
+func (mpr *mapper[T, R]) Stream(ctx context.Context, in []T) (ret <-chan Output[R], err error) {
+	var clt *collector[R]
+
+	if clt, err = mpr.Start(ctx, in, true); err != nil {
+		return
+	}
+	go func() {
+		select {
+		case <-clt.Done:
+		case <-ctx.Done():
+		}
+		clt.Lock()
+		if !clt.Closed {
+			clt.Closed = true
+			close(clt.Ch)
+		}
+		clt.Unlock()
+		clt.Cancel()
+	}()
+	ret = clt.Ch
+
+	return
+}
+
+// Start Добавление задач в таскер и запуск таскера, если он ещё не запущен
+func (mpr *mapper[T, R]) Start(ctx context.Context, in []T, stream bool) (ret *collector[R], err error) {
+	var h Handle
+	var i int
+
+	ret = &collector[R]{
+		Outputs: make([]Output[R], len(in)),
+		Handles: make([]Handle, 0, len(in)),
+		Seen:    make([]bool, len(in)),
+		Left:    len(in),
+		Done:    make(chan struct{}),
+	}
+	if stream {
+		ret.Ch = make(chan Output[R], len(in))
+	}
+	if len(in) == 0 {
+		close(ret.Done)
+		return
+	}
+	for i = range in {
+		if h, err = mpr.Tasker.SubmitCtx(ctx, in[i], notify(ret.Collect(i))); err == nil {
+			ret.Handles = append(ret.Handles, h)
+			err = mpr.Run(ctx)
+		}
+		if err != nil {
+			// Уже добавленные задачи пакета отменяются, так как их результаты не будут получены
+			ret.Lock()
+			ret.Closed = true
+			ret.Unlock()
+			ret.Cancel()
+			ret = nil
+			return
+		}
+	}
+
+	return
+}
+
+// Run Запуск таскера после добавления задачи, если он не запущен
+// Таскер запускается не дожидаясь добавления всех задач пакета, иначе при политике OverflowBlock место в очереди
+// для остальных задач не освободится. Запуск без задач сразу завершается, поэтому таскер запускается после добавления задачи.
+// Ошибка запуска из-за того, что таскер уже запущен параллельным вызовом, не является ошибкой
+func (mpr *mapper[T, R]) Run(ctx context.Context) (err error) {
+	if mpr.Tasker.IsWork() {
+		return
+	}
+	if err = mpr.Tasker.RunContext(ctx).Error(); err != nil && mpr.Tasker.IsWork() {
+		err = nil
+	}
+	return
+}
+
+// Cancel Отмена не завершённых задач, вызывается без захвата блокировки сбора результатов,
+// так как результаты отменённых задач передаются в Collect
+func (clt *collector[R]) Cancel() {
+	for i := range clt.Handles {
+		clt.Handles[i].Cancel()
+	}
+}
+
+// Collect Функция получения результата задачи с указанным порядковым номером
+func (clt *collector[R]) Collect(index int) func(Result) {
+	return func(rsl Result) {
+		var out = Output[R]{Index: index, ID: rsl.ID, Error: rsl.Error, Attempts: rsl.Attempts}
+
+		out.Value, _ = rsl.Value.(R)
+		clt.Lock()
+		defer clt.Unlock()
+		if clt.Closed || clt.Seen[index] {
+			return
+		}
+		clt.Seen[index], clt.Outputs[index] = true, out
+		if clt.Ch != nil {
+			clt.Ch <- out
+		}
+		if clt.Left--; clt.Left == 0 {
+			if clt.Ch != nil {
+				clt.Closed = true
+				close(clt.Ch)
+			}
+			close(clt.Done)
+		}
+	}
+}
+
+// notify Опция задачи, функция вызывается по окончании выполнения задачи
+func notify(fn func(Result)) TaskOption {
+	return func(t *taskParams) { t.Notify = fn }
+}
@@ -0,0 +1,213 @@
+package tasker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWorkerWithResultMap Результаты собираются в порядке входных данных
+func TestWorkerWithResultMap(t *testing.T) {
+	var mpr Mapper[string, int]
+	var out []Output[int]
+	var err error
+
+	mpr = WorkerWithResult(NewTyped[string]().Concurrent(4), func(ctx context.Context, in string) (int, error) {
+		if in == "" {
+			return 0, Permanent(fmt.Errorf("Empty string"))
+		}
+		time.Sleep(time.Millisecond * time.Duration(10-len(in)))
+		return len(in), nil
+	})
+	if out, err = mpr.Map(context.Background(), []string{"a", "bbb", "", "cc", "dddddd"}); err != nil {
+		t.Fatalf("Map error: %v", err)
+	}
+	for i, expected := range []int{1, 3, 0, 2, 6} {
+		if out[i].Index != i || out[i].Value != expected {
+			t.Fatalf("Unexpected output %d: %+v", i, out[i])
+		}
+	}
+	if out[2].Error == nil || out[0].Error != nil {
+		t.Fatalf("Unexpected errors: %+v", out)
+	}
+	if out, err = mpr.Map(context.Background(), nil); err != nil || len(out) != 0 {
+		t.Fatalf("Unexpected empty map: %v, %v", out, err)
+	}
+}
+
+// TestWorkerWithResultStream Результаты передаются в канал в порядке завершения
+func TestWorkerWithResultStream(t *testing.T) {
+	var tasks = NewTyped[string]().Concurrent(2)
+	var mpr Mapper[string, string]
+	var ch <-chan Output[string]
+	var seen = make(map[int]string)
+	var values []interface{}
+	var err error
+
+	tasks.OnComplete(func(rsl TypedResult[string]) { values = append(values, rsl.Value) })
+	mpr = WorkerWithResult(tasks, func(ctx context.Context, in string) (string, error) {
+		return strings.ToUpper(in), nil
+	})
+	if ch, err = mpr.Stream(context.Background(), []string{"x", "y", "z"}); err != nil {
+		t.Fatalf("Stream error: %v", err)
+	}
+	for out := range ch {
+		seen[out.Index] = out.Value
+	}
+	if seen[0] != "X" || seen[1] != "Y" || seen[2] != "Z" || len(seen) != 3 {
+		t.Fatalf("Unexpected stream outputs: %v", seen)
+	}
+	tasks.Wait()
+	if len(values) != 3 {
+		t.Fatalf("Values not passed to OnComplete: %v", values)
+	}
+}
+
+// TestWorkerWithResultCancel Отмена контекста прерывает сбор результатов
+func TestWorkerWithResultCancel(t *testing.T) {
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+	var mpr Mapper[int, int]
+	var out []Output[int]
+	var err error
+
+	defer cancel()
+	mpr = WorkerWithResult(NewTyped[int]().Concurrent(1), func(ctx context.Context, in int) (int, error) {
+		<-ctx.Done()
+		return in, ctx.Err()
+	})
+	if out, err = mpr.Map(ctx, []int{1, 2, 3}); err != context.DeadlineExceeded || len(out) != 3 {
+		t.Fatalf("Unexpected cancel result: %v, %v", out, err)
+	}
+	// Результаты не завершённых задач содержат ошибку контекста, а не выглядят успешными
+	for i := range out {
+		if out[i].Index != i || out[i].Error == nil {
+			t.Fatalf("Unexpected output %d: %+v", i, out[i])
+		}
+	}
+}
+
+// TestWorkerWithResultSubmitError Ошибка добавления задачи отменяет уже добавленные задачи пакета
+func TestWorkerWithResultSubmitError(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1).MaxQueued(1, OverflowFail)
+	var mpr Mapper[int, int]
+	var calls int32
+	var err error
+
+	mpr = WorkerWithResult(tasks, func(ctx context.Context, in int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-ctx.Done()
+		return in, ctx.Err()
+	})
+	if _, err = mpr.Map(context.Background(), []int{1, 2, 3, 4}); err != ErrQueueFull {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tasks.Wait()
+	if n := tasks.GetTasksNumber(); n != 0 {
+		t.Fatalf("Submitted tasks left in queue: %d", n)
+	}
+	// Таскер запускается после добавления первой задачи, отменённая задача могла успеть начать выполнение
+	if n := atomic.LoadInt32(&calls); n > 1 {
+		t.Fatalf("Submitted tasks of failed batch executed: %d", n)
+	}
+}
+
+// TestWorkerWithResultBounded Задачи пакета больше ограничения очереди добавляются по мере выполнения,
+// отмена контекста прерывает ожидание места в очереди
+func TestWorkerWithResultBounded(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(2).MaxQueued(2, OverflowBlock)
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Second*2)
+	var mpr Mapper[int, int]
+	var out []Output[int]
+	var err error
+	var hang bool
+	var started time.Time
+
+	defer cancel()
+	mpr = WorkerWithResult(tasks, func(ctx context.Context, in int) (int, error) {
+		if hang {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return in * 2, nil
+	})
+	if out, err = mpr.Map(ctx, []int{1, 2, 3, 4, 5}); err != nil || len(out) != 5 {
+		t.Fatalf("Map error: %v, %v", out, err)
+	}
+	for i := range out {
+		if out[i].Value != (i+1)*2 || out[i].Error != nil {
+			t.Fatalf("Unexpected output %d: %+v", i, out[i])
+		}
+	}
+	tasks.Wait()
+	hang, started = true, time.Now()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second/10)
+	defer cancel()
+	if _, err = mpr.Map(ctx, []int{1, 2, 3, 4, 5, 6}); err != context.DeadlineExceeded {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if time.Since(started) > time.Second {
+		t.Fatalf("Map ignored context while waiting for queue room")
+	}
+	tasks.Wait()
+	if n := tasks.GetTasksNumber(); n != 0 {
+		t.Fatalf("Submitted tasks left in queue: %d", n)
+	}
+}
+
+// TestWorkerWithResultStartError Ошибка запуска таскера возвращается сразу, добавленные задачи пакета отменяются
+func TestWorkerWithResultStartError(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1)
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Second*2)
+	var mpr Mapper[int, int]
+	var calls int
+	var err error
+
+	defer cancel()
+	tasks.Bootstrap(func([]int) error { return fmt.Errorf("boom") })
+	mpr = WorkerWithResult(tasks, func(ctx context.Context, in int) (int, error) {
+		calls++
+		return in, nil
+	})
+	if _, err = mpr.Map(ctx, []int{1, 2, 3}); err == nil || err.Error() != "boom" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("Map waited for context instead of returning the start error")
+	}
+	if n := tasks.GetTasksNumber(); n != 0 || calls != 0 {
+		t.Fatalf("Tasks of failed batch left in queue or executed: %d, %d", n, calls)
+	}
+}
+
+// TestWorkerWithResultClean Задачи удалённые из очереди функцией Clean() завершают сбор результатов с ошибкой ErrTaskCancelled
+func TestWorkerWithResultClean(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1).Pause()
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Second*2)
+	var mpr Mapper[int, int]
+	var done = make(chan []Output[int], 1)
+
+	defer cancel()
+	mpr = WorkerWithResult(tasks, func(ctx context.Context, in int) (int, error) { return in, nil })
+	go func() {
+		out, _ := mpr.Map(ctx, []int{1, 2})
+		done <- out
+	}()
+	for tasks.GetTasksNumber() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	tasks.Clean()
+	select {
+	case out := <-done:
+		for i := range out {
+			if out[i].Error != ErrTaskCancelled {
+				t.Fatalf("Unexpected output %d: %+v", i, out[i])
+			}
+		}
+	case <-ctx.Done():
+		t.Fatalf("Map over cleaned tasks did not complete")
+	}
+	tasks.Resume().Wait()
+}
@@ -0,0 +1,122 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"container/list"
+	"context"
+	"time"
+)
+
+// OverflowPolicy Поведение при добавлении задачи в заполненную очередь
+type OverflowPolicy int
+
+const (
+	// OverflowBlock Ожидать освобождения места в очереди
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowFail Не добавлять задачу и вернуть ошибку ErrQueueFull
+	OverflowFail
+
+	// OverflowDropOldest Удалить из очереди самую старую ожидающую запуска задачу и добавить новую
+	OverflowDropOldest
+
+	// OverflowDropNewest Не добавлять новую задачу, задача завершается с ошибкой ErrTaskDropped
+	OverflowDropNewest
+)
+
+// MaxQueued Ограничение количества задач ожидающих запуска, выполняющиеся задачи не учитываются
+// По умолчанию 0 - не ограничено. Удалённые задачи завершаются с ошибкой ErrTaskDropped и учитываются в Stats().Dropped
+func (tsk *implementation[T]) MaxQueued(n int, policy OverflowPolicy) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	if n >= 0 {
+		tsk.MaxQueue, tsk.Overflow = n, policy
+		tsk.Vacate()
+	}
+	return tsk
+}
+
+// AddTaskCtx Добавление одного объекта задачи в очередь выполнения
+// При политике OverflowBlock функция блокируется до освобождения места в очереди или до отмены контекста
+func (tsk *implementation[T]) AddTaskCtx(ctx context.Context, t T, opts ...TaskOption) (err error) {
+	_, err = tsk.SubmitCtx(ctx, t, opts...)
+	return
+}
+
+// IsFull Очередь задач ожидающих запуска заполнена
+func (tsk *implementation[T]) IsFull() bool {
+	return tsk.MaxQueue > 0 && tsk.Tasks.Len()-tsk.InFlight >= tsk.MaxQueue
+}
+
+// Admit Ожидание места в очереди при политике OverflowBlock, вызывается под блокировкой таскера
+// На время ожидания блокировка таскера освобождается
+func (tsk *implementation[T]) Admit(ctx context.Context) (err error) {
+	var room chan struct{}
+
+	for tsk.Overflow == OverflowBlock && tsk.IsFull() {
+		room = tsk.Room
+		tsk.Producers++
+		tsk.Unlock()
+		select {
+		case <-room:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		tsk.Lock()
+		if tsk.Producers--; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// Overflowed Добавление задачи в заполненную очередь согласно политике переполнения
+// =true - новая задача удалена и не должна добавляться в очередь
+func (tsk *implementation[T]) Overflowed(item *task[T]) (dropped bool, err error) {
+	var elm *list.Element
+	var old *task[T]
+
+	switch tsk.Overflow {
+	case OverflowDropNewest:
+		// Задача не попадает в очередь, поэтому итог запоминается здесь, зависящие от неё задачи получат ErrDependencyFailed
+		tsk.Drop(item)
+		tsk.Remember(item.ID, false)
+		dropped = true
+	case OverflowDropOldest:
+		for elm = tsk.Tasks.Front(); elm != nil; elm = elm.Next() {
+			if old = elm.Value.(*task[T]); !old.InWork {
+				tsk.Drop(old)
+				return
+			}
+		}
+		err = ErrQueueFull
+	default:
+		err = ErrQueueFull
+	}
+
+	return
+}
+
+// Drop Удаление задачи из-за переполнения очереди, задачи зависящие от удалённой задачи пропускаются
+func (tsk *implementation[T]) Drop(item *task[T]) {
+	tsk.Dropped++
+	tsk.Discard(item, ErrTaskDropped)
+}
+
+// Discard Удаление не запущенной задачи из очереди с итоговой ошибкой err, задачи зависящие от удалённой задачи пропускаются
+func (tsk *implementation[T]) Discard(item *task[T], err error) {
+	var r = &result[T]{Task: item, Error: err, Finished: time.Now(), WorkerID: -1}
+
+	if item.Element != nil {
+		tsk.Tasks.Remove(item.Element)
+		item.Element = nil
+	}
+	tsk.Dequeue(item)
+	item.Err = err
+	if tsk.Pending[item.ID] == item {
+		tsk.Resolve(item, err)
+	}
+	tsk.Complete(r)
+}
@@ -0,0 +1,116 @@
+package tasker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestMaxQueuedFail Добавление в заполненную очередь завершается ошибкой
+func TestMaxQueuedFail(t *testing.T) {
+	var tasks = NewTyped[int]().MaxQueued(2, OverflowFail).Worker(func(int) error { return nil })
+
+	if tasks.AddTask(1) != nil || tasks.AddTask(2) != nil {
+		t.Fatalf("Tasks not added")
+	}
+	if err := tasks.AddTask(3); err != ErrQueueFull {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tasks.Run().Wait()
+	if err := tasks.AddTask(3); err != nil {
+		t.Fatalf("Task not added after queue drained: %v", err)
+	}
+}
+
+// TestMaxQueuedDrop Удаление самой старой или новой задачи при переполнении очереди
+func TestMaxQueuedDrop(t *testing.T) {
+	var done []int
+	var dropped []int
+	var tasks Typed[int]
+	var h Handle
+	var err error
+
+	tasks = NewTyped[int]().
+		Concurrent(1).
+		Worker(func(in int) error { done = append(done, in); return nil }).
+		OnComplete(func(rsl TypedResult[int]) {
+			if rsl.Error == ErrTaskDropped {
+				dropped = append(dropped, rsl.Body)
+			}
+		})
+
+	tasks.MaxQueued(2, OverflowDropOldest)
+	for i := 1; i <= 4; i++ {
+		if err = tasks.AddTask(i); err != nil {
+			t.Fatalf("AddTask error: %v", err)
+		}
+	}
+	tasks.MaxQueued(2, OverflowDropNewest)
+	if h, err = tasks.Submit(5); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	select {
+	case <-h.Done():
+	default:
+		t.Fatalf("Dropped task not done")
+	}
+	if _, err = tasks.Submit(6, DependsOn(h.ID())); err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	if stats := tasks.Stats(); stats.Dropped != 4 || stats.Total != 2 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	tasks.Run().Wait()
+	if len(done) != 2 || done[0] != 3 || done[1] != 4 {
+		t.Fatalf("Unexpected executed tasks: %v", done)
+	}
+	if len(dropped) != 4 || dropped[0] != 1 || dropped[1] != 2 || dropped[2] != 5 || dropped[3] != 6 {
+		t.Fatalf("Unexpected dropped tasks: %v", dropped)
+	}
+	// Отброшенная при добавлении задача считается не выполненной
+	if _, err = tasks.Submit(7, DependsOn(h.ID())); err != ErrDependencyFailed {
+		t.Fatalf("Unexpected dependency on dropped task error: %v", err)
+	}
+}
+
+// TestMaxQueuedBlock Добавление задачи ожидает освобождения места в очереди
+func TestMaxQueuedBlock(t *testing.T) {
+	var release = make(chan struct{})
+	var tasks Typed[int]
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var added = make(chan error, 1)
+
+	tasks = NewTyped[int]().
+		Concurrent(1).
+		MaxQueued(1, OverflowBlock).
+		Worker(func(int) error { <-release; return nil })
+	if tasks.AddTask(1) != nil {
+		t.Fatalf("Task not added")
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	if err := tasks.AddTaskCtx(ctx, 2); err != context.DeadlineExceeded {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tasks.Run()
+	// Первая задача выполняется, место в очереди освободилось
+	if err := tasks.AddTaskCtx(context.Background(), 2); err != nil {
+		t.Fatalf("AddTaskCtx error: %v", err)
+	}
+	go func() { added <- tasks.AddTaskCtx(context.Background(), 3) }()
+	select {
+	case <-added:
+		t.Fatalf("Task added to full queue")
+	case <-time.After(time.Millisecond * 20):
+	}
+	close(release)
+	if err := <-added; err != nil {
+		t.Fatalf("AddTaskCtx error: %v", err)
+	}
+	// Задача могла быть добавлена после завершения менеджера, тогда она выполнится при следующем запуске
+	tasks.Wait().Run().Wait()
+	if n := tasks.GetTasksNumber(); n != 0 {
+		t.Fatalf("Unexpected number of tasks: %d", n)
+	}
+}
@@ -0,0 +1,71 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"fmt"
+)
+
+// Pause Приостановка запуска задач без остановки работников
+// Выполняющиеся задачи завершаются как обычно, новые задачи и предварительная обработка задач функцией BootstrapFunc
+// не запускаются до вызова Resume(). Приостановленный таскер с задачами в очереди не завершается и Wait() его ожидает
+func (tsk *implementation[T]) Pause() Typed[T] {
+	tsk.SetPaused(true)
+	return tsk
+}
+
+// Resume Возобновление запуска задач приостановленного таскера
+func (tsk *implementation[T]) Resume() Typed[T] {
+	tsk.SetPaused(false)
+	return tsk
+}
+
+// IsPaused =true - запуск задач приостановлен
+func (tsk *implementation[T]) IsPaused() bool {
+	tsk.Lock()
+	defer tsk.Unlock()
+	return tsk.Paused
+}
+
+// OnPauseChange Установка функции вызываемой при приостановке и возобновлении запуска задач
+// Функция вызывается без захвата блокировки таскера в процессе вызвавшем Pause() или Resume(), только при изменении состояния
+// Вызовы функции упорядочены так же, как изменения состояния, поэтому функция не должна вызывать Pause() или Resume()
+func (tsk *implementation[T]) OnPauseChange(fn func(paused bool)) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	tsk.OnPauseFn = fn
+	return tsk
+}
+
+// SetPaused Изменение состояния приостановки запуска задач с вызовом функции OnPauseChange
+// Изменение состояния и вызов функции выполняются под блокировкой PauseLock, поэтому при одновременных вызовах
+// Pause() и Resume() последнее событие соответствует текущему состоянию
+func (tsk *implementation[T]) SetPaused(paused bool) {
+	var fn func(bool)
+
+	tsk.PauseLock.Lock()
+	defer tsk.PauseLock.Unlock()
+	tsk.Lock()
+	if tsk.Paused == paused {
+		tsk.Unlock()
+		return
+	}
+	tsk.Paused, fn = paused, tsk.OnPauseFn
+	tsk.Unlock()
+	tsk.Signal()
+	if fn != nil {
+		tsk.SafeCallOnPauseChange(fn, paused)
+	}
+}
+
+// SafeCallOnPauseChange Безопасный запуск внешней функции OnPauseChange
+func (tsk *implementation[T]) SafeCallOnPauseChange(fn func(bool), paused bool) {
+	defer func() {
+		if e := recover(); e != nil {
+			tsk.Lock()
+			tsk.Err = fmt.Errorf("Recovery panic call external OnPauseChange: %v", e)
+			tsk.Unlock()
+		}
+	}()
+	fn(paused)
+}
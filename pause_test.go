@@ -0,0 +1,103 @@
+package tasker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestPauseResume Приостановка запуска задач без остановки работников
+func TestPauseResume(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(2)
+	var release = make(chan struct{})
+	var mu sync.Mutex
+	var events []bool
+	var done int
+
+	tasks.OnPauseChange(func(paused bool) { mu.Lock(); events = append(events, paused); mu.Unlock() })
+	tasks.Worker(func(in int) error {
+		if in < 2 {
+			<-release
+		}
+		mu.Lock()
+		done++
+		mu.Unlock()
+		return nil
+	})
+	for i := 0; i < 10; i++ {
+		tasks.AddTask(i)
+	}
+	tasks.Run()
+	waitStats(t, tasks, func(s Stats) bool { return s.InWork == 2 })
+	tasks.Pause().Pause()
+	if !tasks.IsPaused() {
+		t.Fatalf("Tasker not paused")
+	}
+	close(release)
+	waitStats(t, tasks, func(s Stats) bool { return s.InWork == 0 })
+	time.Sleep(time.Millisecond * 20)
+	if stats := tasks.Stats(); stats.Ready != 8 || stats.Workers != 2 || !tasks.IsWork() {
+		t.Fatalf("Unexpected stats of paused tasker: %+v", stats)
+	}
+	tasks.Resume().Wait()
+	if done != 10 || tasks.IsPaused() {
+		t.Fatalf("Unexpected number of done tasks: %d", done)
+	}
+	if len(events) != 2 || !events[0] || events[1] {
+		t.Fatalf("Unexpected pause events: %v", events)
+	}
+}
+
+// TestPauseBootstrap Предварительная обработка новых задач приостановленного таскера откладывается до возобновления
+func TestPauseBootstrap(t *testing.T) {
+	var tasks = NewTyped[int]().Pause()
+	var mu sync.Mutex
+	var prelude, done int
+
+	tasks.Bootstrap(func(in []int) error { mu.Lock(); prelude += len(in); mu.Unlock(); return nil })
+	tasks.Worker(func(int) error { mu.Lock(); done++; mu.Unlock(); return nil })
+	tasks.AddTasks([]int{1, 2, 3})
+	tasks.Run()
+	time.Sleep(time.Millisecond * 20)
+	mu.Lock()
+	if prelude != 0 || done != 0 {
+		t.Fatalf("Paused tasker executed bootstrap %d or tasks %d", prelude, done)
+	}
+	mu.Unlock()
+	if stats := tasks.Stats(); stats.New != 3 {
+		t.Fatalf("Unexpected stats of paused tasker: %+v", stats)
+	}
+	tasks.Resume().Wait()
+	if prelude != 3 || done != 3 {
+		t.Fatalf("Unexpected number of processed tasks: bootstrap %d, done %d", prelude, done)
+	}
+}
+
+// TestPauseEventsOrder События приостановки передаются в порядке изменения состояния
+func TestPauseEventsOrder(t *testing.T) {
+	var tasks = NewTyped[int]().Worker(func(int) error { return nil })
+	var events []bool
+	var wg sync.WaitGroup
+
+	tasks.OnPauseChange(func(paused bool) { events = append(events, paused) })
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(pause bool) {
+			defer wg.Done()
+			if pause {
+				tasks.Pause()
+				return
+			}
+			tasks.Resume()
+		}(i%2 == 0)
+	}
+	wg.Wait()
+	for i := range events {
+		if events[i] != (i%2 == 0) {
+			t.Fatalf("Unexpected pause events order: %v", events)
+		}
+	}
+	if len(events) > 0 && events[len(events)-1] != tasks.IsPaused() {
+		t.Fatalf("Last pause event differs from state: %v", events)
+	}
+}
@@ -0,0 +1,203 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Persist Подключение хранилища задач вместо хранилища в памяти процесса, объекты задач сериализуются функциями codec,
+// nil - сериализация в JSON. Не завершённые задачи хранилища добавляются в очередь с прежними идентификаторами, задачи переданные работнику
+// и не подтверждённые до аварийного завершения процесса запускаются повторно. Зависимости от задач отсутствующих
+// в хранилище считаются выполненными. Хранилище подключается только к остановленному таскеру с пустой очередью,
+// задачи исчерпавшие попытки выполнения в хранилище не сохраняются
+func (tsk *implementation[T]) Persist(store Store, codec Codec) (err error) {
+	var backend Store
+	var bodyCodec Codec
+
+	tsk.Lock()
+	defer tsk.Unlock()
+	switch {
+	case tsk.isWork:
+		err = fmt.Errorf("Tasker already running")
+		return
+	case store == nil:
+		err = fmt.Errorf("Not specified task store")
+		return
+	case tsk.Tasks.Len() > 0:
+		err = fmt.Errorf("Task queue is not empty")
+		return
+	}
+	if codec == nil {
+		codec = NewJSONCodec()
+	}
+	backend, bodyCodec = tsk.Backend, tsk.BodyCodec
+	tsk.Backend, tsk.BodyCodec = store, codec
+	if err = tsk.Recover(); err != nil {
+		tsk.Backend, tsk.BodyCodec = backend, bodyCodec
+	}
+
+	return
+}
+
+// Recover Восстановление не завершённых задач из хранилища
+// При ошибке очередь остаётся пустой, как до подключения хранилища
+func (tsk *implementation[T]) Recover() (err error) {
+	var stored []StoredTask
+	var items []*task[T]
+	var item *task[T]
+	var last = tsk.LastID
+	var i int
+
+	if stored, err = tsk.Backend.List(); err != nil {
+		err = fmt.Errorf("Task store list error: %s", err)
+		return
+	}
+	// Все объекты задач восстанавливаются до изменения очереди, чтобы ошибка не оставила очередь заполненной частично
+	items = make([]*task[T], len(stored))
+	for i = range stored {
+		item = &task[T]{
+			ID:         stored[i].ID,
+			Index:      -1,
+			TimerIndex: -1,
+			Attempts:   stored[i].Attempts,
+			CountError: stored[i].CountError,
+			Prelude:    stored[i].Prelude,
+			Encoded:    stored[i].Body,
+			Saved:      true,
+			Completed:  make(chan struct{}),
+		}
+		item.Created, item.NotBefore = stored[i].Created, stored[i].NotBefore
+		item.Priority, item.Timeout, item.Key = stored[i].Priority, stored[i].Timeout, stored[i].Key
+		if err = tsk.BodyCodec.Unmarshal(stored[i].Body, &item.Body); err != nil {
+			err = fmt.Errorf("Decode task %d error: %w", stored[i].ID, err)
+			return
+		}
+		items[i] = item
+	}
+	for i, item = range items {
+		for _, id := range stored[i].Deps {
+			if tsk.Pending[id] != nil {
+				item.Deps = append(item.Deps, id)
+			}
+		}
+		if err = tsk.Link(item); err != nil {
+			tsk.Unrecover(last)
+			return
+		}
+		if item.ID > tsk.LastID {
+			tsk.LastID = item.ID
+		}
+		tsk.Pending[item.ID] = item
+		item.Element = tsk.Tasks.PushBack(item)
+		tsk.Claim(item)
+		tsk.Serialize(item)
+		if stored[i].InWork {
+			tsk.Nack(item)
+		}
+		// Задачи уже обработанные BootstrapFunc до аварийного завершения процесса повторно не обрабатываются
+		if item.Prelude {
+			tsk.Enqueue(item)
+		} else {
+			tsk.Fresh = append(tsk.Fresh, item)
+		}
+	}
+
+	return
+}
+
+// Unrecover Возврат пустой очереди после ошибки восстановления задач из хранилища
+// Хранилище подключается только к пустой очереди, поэтому все задачи очереди добавлены восстановлением
+func (tsk *implementation[T]) Unrecover(last TaskID) {
+	tsk.Tasks.Init()
+	tsk.Pending = make(map[TaskID]*task[T])
+	tsk.Keys, tsk.Lines = nil, nil
+	tsk.Ready.Reset()
+	tsk.Scheduled.Reset()
+	tsk.Fresh = tsk.Fresh[:0]
+	tsk.LastID = last
+}
+
+// Save Сохранение задачи ожидающей запуска в хранилище, вызывается под блокировкой таскера
+// Объект задачи сериализуется, только если для хранилища задан Codec
+func (tsk *implementation[T]) Save(item *task[T]) (err error) {
+	if tsk.Backend == nil {
+		return
+	}
+	if tsk.BodyCodec != nil && item.Encoded == nil {
+		if item.Encoded, err = tsk.BodyCodec.Marshal(tsk.Marshalled(item)); err != nil {
+			err = fmt.Errorf("Encode task error: %w", err)
+			return
+		}
+	}
+	if err = tsk.Backend.Enqueue(item.Stored()); err != nil {
+		err = fmt.Errorf("Task store error: %s", err)
+		return
+	}
+	item.Saved = true
+	return
+}
+
+// Marshalled Объект задачи для передачи в Codec.Marshal
+// Объект задачи интерфейсного типа передаётся указателем, чтобы Codec мог потребовать регистрации типа объекта
+func (tsk *implementation[T]) Marshalled(item *task[T]) interface{} {
+	if reflect.TypeOf((*T)(nil)).Elem().Kind() == reflect.Interface {
+		return &item.Body
+	}
+	return item.Body
+}
+
+// Lease Отметка в хранилище о передаче задачи работнику, ошибка хранилища сохраняется в Err
+func (tsk *implementation[T]) Lease(item *task[T]) {
+	if tsk.Backend == nil || !item.Saved {
+		return
+	}
+	if err := tsk.Backend.Lease(item.ID); err != nil {
+		tsk.Err = fmt.Errorf("Task store error: %s", err)
+	}
+}
+
+// Ack Удаление завершённой задачи из хранилища, ошибка хранилища сохраняется в Err
+func (tsk *implementation[T]) Ack(item *task[T]) {
+	if tsk.Backend == nil || !item.Saved {
+		return
+	}
+	if err := tsk.Backend.Ack(item.ID); err != nil {
+		tsk.Err = fmt.Errorf("Task store error: %s", err)
+	}
+}
+
+// Nack Возврат задачи в очередь хранилища, ошибка хранилища сохраняется в Err
+func (tsk *implementation[T]) Nack(item *task[T]) {
+	if tsk.Backend == nil || !item.Saved {
+		return
+	}
+	if err := tsk.Backend.Nack(item.Stored()); err != nil {
+		tsk.Err = fmt.Errorf("Task store error: %s", err)
+	}
+}
+
+// Stored Запись задачи для хранилища
+func (t *task[T]) Stored() (ret StoredTask) {
+	ret = StoredTask{
+		ID:         t.ID,
+		Body:       t.Encoded,
+		Priority:   t.Priority,
+		Timeout:    t.Timeout,
+		Created:    t.Created,
+		NotBefore:  t.NotBefore,
+		Deps:       t.Deps,
+		Key:        t.Key,
+		Prelude:    t.Prelude,
+		InWork:     t.InWork,
+		Attempts:   t.Attempts,
+		CountError: t.CountError,
+		Updated:    time.Now(),
+	}
+	if n := len(t.History); n > 0 && t.History[n-1].Error != nil {
+		ret.Error = t.History[n-1].Error.Error()
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"container/heap"
+	"math"
+	"time"
+)
+
+// queue Очередь готовых к запуску задач, упорядоченная по приоритету (container/heap)
+// Задачи с одинаковым приоритетом извлекаются в порядке добавления в таскер
+type queue[T any] struct {
+	Items []*task[T]    // Задачи
+	Aging time.Duration // Интервал ожидания, за который приоритет задачи повышается на единицу. 0 - без повышения
+}
+
+// Len Реализация интерфейса heap.Interface
+func (q *queue[T]) Len() int { return len(q.Items) }
+
+// Less Реализация интерфейса heap.Interface
+func (q *queue[T]) Less(i, j int) bool {
+	var pi, pj int64
+
+	if q.Aging > 0 {
+		pi, pj = q.Rank(q.Items[i]), q.Rank(q.Items[j])
+	} else {
+		pi, pj = int64(q.Items[i].Priority), int64(q.Items[j].Priority)
+	}
+	if pi != pj {
+		return pi > pj
+	}
+	return q.Items[i].ID < q.Items[j].ID
+}
+
+// Swap Реализация интерфейса heap.Interface
+func (q *queue[T]) Swap(i, j int) {
+	q.Items[i], q.Items[j] = q.Items[j], q.Items[i]
+	q.Items[i].Index, q.Items[j].Index = i, j
+}
+
+// Push Реализация интерфейса heap.Interface
+func (q *queue[T]) Push(x interface{}) {
+	var item = x.(*task[T])
+
+	item.Index = len(q.Items)
+	q.Items = append(q.Items, item)
+}
+
+// Pop Реализация интерфейса heap.Interface
+func (q *queue[T]) Pop() interface{} {
+	var n = len(q.Items) - 1
+	var item = q.Items[n]
+
+	q.Items[n] = nil
+	q.Items = q.Items[:n]
+	item.Index = -1
+	return item
+}
+
+// Rank Ключ порядка задачи при повышении приоритета за время ожидания
+// Приоритет задачи, выраженный в интервалах ожидания, за вычетом времени помещения задачи в очередь. Ключ не зависит
+// от текущего времени, поэтому порядок задач в куче не меняется со временем и не требует перестроения кучи
+func (q *queue[T]) Rank(item *task[T]) int64 {
+	var limit = math.MaxInt64 / 4 / int64(q.Aging)
+	var priority = min(max(int64(item.Priority), -limit), limit)
+
+	return priority*int64(q.Aging) - item.Queued.UnixNano()
+}
+
+// Add Добавление задачи в очередь
+func (q *queue[T]) Add(item *task[T]) {
+	item.Queued = time.Now()
+	heap.Push(q, item)
+}
+
+// Next Извлечение задачи с наибольшим приоритетом, nil если очередь пуста
+func (q *queue[T]) Next() *task[T] {
+	if len(q.Items) == 0 {
+		return nil
+	}
+	return heap.Pop(q).(*task[T])
+}
+
+// NextFunc Извлечение задачи с наибольшим приоритетом из задач удовлетворяющих условию, nil если таких задач нет
+// Пропущенные задачи остаются в очереди с прежним временем помещения в очередь, кроме отложенных функцией условия (Parked, Limited)
+func (q *queue[T]) NextFunc(ok func(*task[T]) bool) (ret *task[T]) {
+	var skipped []*task[T]
+
+	if len(q.Items) == 0 {
+		return
+	}
+	for len(q.Items) > 0 {
+		if ret = heap.Pop(q).(*task[T]); ok(ret) {
+			break
+		}
+		skipped, ret = append(skipped, ret), nil
+	}
+	for i := range skipped {
+		if !skipped[i].Parked && !skipped[i].Limited {
+			heap.Push(q, skipped[i])
+		}
+	}
+	return
+}
+
+// Remove Удаление задачи из очереди, если она в очереди
+func (q *queue[T]) Remove(item *task[T]) {
+	if item.Index < 0 || item.Index >= len(q.Items) || q.Items[item.Index] != item {
+		return
+	}
+	heap.Remove(q, item.Index)
+}
+
+// SetAging Изменение интервала повышения приоритета с перестроением очереди
+func (q *queue[T]) SetAging(d time.Duration) {
+	if q.Aging != d {
+		q.Aging = d
+		heap.Init(q)
+	}
+}
+
+// Reset Удаление всех задач из очереди
+func (q *queue[T]) Reset() {
+	for i := range q.Items {
+		q.Items[i].Index = -1
+	}
+	q.Items = q.Items[:0]
+}
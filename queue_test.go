@@ -0,0 +1,104 @@
+package tasker
+
+import (
+	"container/heap"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestPriority Задачи запускаются в порядке приоритета, с одинаковым приоритетом в порядке добавления
+func TestPriority(t *testing.T) {
+	var tasks Tasker
+	var order []interface{}
+
+	tasks = NewTasker().
+		Concurrent(1).
+		Worker(func(in interface{}) error {
+			order = append(order, in)
+			return nil
+		})
+	_ = tasks.AddTask("bulk 1")
+	_ = tasks.AddTaskWithPriority("urgent 1", 10)
+	_ = tasks.AddTask("bulk 2")
+	_ = tasks.AddTask("normal 1", WithPriority(5))
+	_ = tasks.AddTaskWithPriority("urgent 2", 10)
+	_ = tasks.AddTaskWithPriority("background", -1)
+	tasks.Run().Wait()
+
+	if expected := []interface{}{"urgent 1", "urgent 2", "normal 1", "bulk 1", "bulk 2", "background"}; !reflect.DeepEqual(order, expected) {
+		t.Fatalf("Unexpected order: %v", order)
+	}
+}
+
+// TestQueueAging Повышение приоритета долго ожидающих задач
+func TestQueueAging(t *testing.T) {
+	var q = &queue[interface{}]{Aging: time.Second}
+	var now = time.Now()
+	var old = &task[interface{}]{ID: 1, taskParams: taskParams{Priority: 0}, Queued: now.Add(-time.Second * 5)}
+	var fresh = &task[interface{}]{ID: 2, taskParams: taskParams{Priority: 3}, Queued: now}
+
+	heap.Push(q, fresh)
+	heap.Push(q, old)
+	if item := q.Next(); item != old {
+		t.Fatalf("Aged task not dispatched first: %d", item.ID)
+	}
+	if item := q.Next(); item != fresh || q.Next() != nil {
+		t.Fatalf("Unexpected queue state")
+	}
+
+	q.Aging = 0
+	q.Add(old)
+	q.Add(fresh)
+	q.Remove(fresh)
+	if q.Len() != 1 || fresh.Index != -1 {
+		t.Fatalf("Task not removed from queue")
+	}
+}
+
+// TestQueueAgingOrder Порядок извлечения задач при повышении приоритета совпадает с порядком
+// по приоритету с учётом времени ожидания, вычисленному в момент извлечения
+func TestQueueAgingOrder(t *testing.T) {
+	var q = &queue[interface{}]{Aging: time.Millisecond * 10}
+	var rnd = rand.New(rand.NewSource(1))
+	var now = time.Now()
+	var items []*task[interface{}]
+	var effective = func(item *task[interface{}]) float64 {
+		return float64(item.Priority) + float64(now.Sub(item.Queued))/float64(q.Aging)
+	}
+
+	for i := 1; i <= 500; i++ {
+		items = append(items, &task[interface{}]{
+			ID:         TaskID(i),
+			taskParams: taskParams{Priority: rnd.Intn(20) - 10},
+			Queued:     now.Add(-time.Duration(rnd.Int63n(int64(time.Second)))),
+		})
+		heap.Push(q, items[len(items)-1])
+		// Извлечение задач вперемешку с добавлением не нарушает порядок кучи
+		if i%7 == 0 {
+			items = popBest(t, q, items, effective)
+		}
+	}
+	for len(items) > 0 {
+		items = popBest(t, q, items, effective)
+	}
+	if q.Next() != nil {
+		t.Fatalf("Queue not empty")
+	}
+}
+
+// popBest Извлечение задачи из очереди с проверкой, что у неё наибольший приоритет среди оставшихся задач
+func popBest(t *testing.T, q *queue[interface{}], items []*task[interface{}], effective func(*task[interface{}]) float64) []*task[interface{}] {
+	var item = q.Next()
+	var n int
+
+	for i := range items {
+		if items[i] == item {
+			n = i
+		} else if effective(items[i]) > effective(item) {
+			t.Fatalf("Task %d dispatched before task %d with higher priority", item.ID, items[i].ID)
+		}
+	}
+	return append(items[:n], items[n+1:]...)
+}
@@ -0,0 +1,224 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"container/heap"
+	"time"
+)
+
+// Количество ключей ограничения скорости, после превышения которого удаляются неиспользуемые корзины
+const keyLimitPrune = 1024
+
+// bucket Корзина токенов ограничения скорости запуска задач
+type bucket struct {
+	Rate   float64   // Скорость пополнения корзины, токенов в секунду
+	Burst  int       // Вместимость корзины
+	Tokens float64   // Текущее количество токенов
+	Last   time.Time // Время последнего пополнения корзины
+}
+
+// keyLimit Ограничение скорости запуска задач с отдельной корзиной для каждого ключа
+type keyLimit[T any] struct {
+	Key     func(T) string       // Функция получения ключа из объекта задачи
+	Rate    float64              // Скорость пополнения корзин, токенов в секунду
+	Burst   int                  // Вместимость корзин
+	Buckets map[string]*bucket   // Корзины по ключам
+	Waiting map[string]*queue[T] // Задачи ключей, исчерпавших ограничение, по ключам
+}
+
+// newBucket Создание заполненной корзины токенов
+func newBucket(rps float64, burst int, now time.Time) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{Rate: rps, Burst: burst, Tokens: float64(burst), Last: now}
+}
+
+// RateLimit Ограничение скорости запуска задач, не более rps запусков в секунду с допустимым всплеском до burst запусков
+// Значение rps <= 0 снимает ограничение
+func (tsk *implementation[T]) RateLimit(rps float64, burst int) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	if tsk.Limit = nil; rps > 0 {
+		tsk.Limit = newBucket(rps, burst, time.Now())
+	}
+	tsk.Signal()
+	return tsk
+}
+
+// RateLimitBy Ограничение скорости запуска задач с отдельным ограничением для каждого ключа, полученного функцией key из объекта задачи
+// Задачи ключей, исчерпавших ограничение, пропускаются, и запускаются задачи других ключей. Значение rps <= 0 или key = nil снимает ограничение
+func (tsk *implementation[T]) RateLimitBy(key func(T) string, rps float64, burst int) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	tsk.UnthrottleAll()
+	if tsk.KeyLimit = nil; key != nil && rps > 0 {
+		tsk.KeyLimit = &keyLimit[T]{
+			Key:     key,
+			Rate:    rps,
+			Burst:   burst,
+			Buckets: make(map[string]*bucket),
+			Waiting: make(map[string]*queue[T]),
+		}
+	}
+	tsk.Signal()
+	return tsk
+}
+
+// Eligible Можно ли запустить задачу с учётом ограничений групп и ограничения скорости по ключам
+// Задача ключа, исчерпавшего ограничение скорости, откладывается до пополнения корзины ключа,
+// и запоминается время когда ограничение будет снято
+func (tsk *implementation[T]) Eligible(item *task[T], now time.Time) bool {
+	var b *bucket
+	var key string
+
+	if tsk.Saturated(item) {
+		return false
+	}
+	if tsk.KeyLimit == nil {
+		return true
+	}
+	key = tsk.KeyLimit.SafeKey(item.Body)
+	if b = tsk.KeyLimit.Bucket(key, now); b.Allow(now) {
+		return true
+	}
+	tsk.Throttle(now.Add(b.Delay()))
+	tsk.Restrain(item, key)
+	return false
+}
+
+// Restrain Откладывание задачи ключа, исчерпавшего ограничение скорости, время помещения задачи в очередь сохраняется
+func (tsk *implementation[T]) Restrain(item *task[T], key string) {
+	var q *queue[T]
+	var ok bool
+
+	if q, ok = tsk.KeyLimit.Waiting[key]; !ok {
+		q = &queue[T]{Aging: tsk.Ready.Aging}
+		tsk.KeyLimit.Waiting[key] = q
+	}
+	item.Limited, item.LimitKey = true, key
+	heap.Push(q, item)
+}
+
+// Unthrottle Возврат в очередь готовых к запуску отложенных задач ключей, корзины которых пополнились,
+// задач ключа возвращается не больше чем токенов в корзине ключа, для остальных ключей запоминается время снятия ограничения
+func (tsk *implementation[T]) Unthrottle(now time.Time) {
+	var q *queue[T]
+	var b *bucket
+	var item *task[T]
+	var key string
+
+	if tsk.KeyLimit == nil {
+		return
+	}
+	for key, q = range tsk.KeyLimit.Waiting {
+		if b = tsk.KeyLimit.Bucket(key, now); !b.Allow(now) {
+			tsk.Throttle(now.Add(b.Delay()))
+			continue
+		}
+		for n := int(b.Tokens); n > 0 && q.Len() > 0; n-- {
+			item = q.Next()
+			item.Limited = false
+			heap.Push(tsk.Ready, item)
+		}
+		if q.Len() == 0 {
+			delete(tsk.KeyLimit.Waiting, key)
+		}
+	}
+}
+
+// UnthrottleAll Возврат в очередь готовых к запуску всех задач, отложенных ограничением скорости по ключам
+func (tsk *implementation[T]) UnthrottleAll() {
+	var q *queue[T]
+	var i int
+
+	if tsk.KeyLimit == nil {
+		return
+	}
+	for _, q = range tsk.KeyLimit.Waiting {
+		for i = range q.Items {
+			q.Items[i].Limited = false
+			heap.Push(tsk.Ready, q.Items[i])
+		}
+		q.Items = nil
+	}
+	tsk.KeyLimit.Waiting = make(map[string]*queue[T])
+}
+
+// Throttle Запоминание ближайшего времени снятия ограничения скорости для пробуждения менеджера
+func (tsk *implementation[T]) Throttle(at time.Time) {
+	if tsk.Throttled.IsZero() || at.Before(tsk.Throttled) {
+		tsk.Throttled = at
+	}
+}
+
+// Acquire Расход токенов на запуск задачи и учёт задачи в её группе
+func (tsk *implementation[T]) Acquire(item *task[T], now time.Time) {
+	tsk.Occupy(item)
+	if tsk.Limit != nil {
+		tsk.Limit.Take()
+	}
+	if tsk.KeyLimit != nil {
+		tsk.KeyLimit.Bucket(tsk.KeyLimit.SafeKey(item.Body), now).Take()
+	}
+}
+
+// SafeKey Безопасный вызов внешней функции получения ключа, при панике используется пустой ключ
+func (kl *keyLimit[T]) SafeKey(body T) (ret string) {
+	defer func() {
+		if e := recover(); e != nil {
+			ret = ""
+		}
+	}()
+	ret = kl.Key(body)
+	return
+}
+
+// Bucket Корзина токенов ключа, создаётся при первом обращении
+// Заполненные корзины неотличимы от новых, поэтому при большом количестве ключей они удаляются
+func (kl *keyLimit[T]) Bucket(key string, now time.Time) (ret *bucket) {
+	var ok bool
+
+	if ret, ok = kl.Buckets[key]; ok {
+		return
+	}
+	if len(kl.Buckets) >= keyLimitPrune {
+		for k, b := range kl.Buckets {
+			if b.Refill(now); b.Tokens >= float64(b.Burst) {
+				delete(kl.Buckets, k)
+			}
+		}
+	}
+	ret = newBucket(kl.Rate, kl.Burst, now)
+	kl.Buckets[key] = ret
+	return
+}
+
+// Refill Пополнение корзины токенами за прошедшее время
+func (b *bucket) Refill(now time.Time) {
+	if now.After(b.Last) {
+		b.Tokens += now.Sub(b.Last).Seconds() * b.Rate
+		b.Last = now
+	}
+	if b.Tokens > float64(b.Burst) {
+		b.Tokens = float64(b.Burst)
+	}
+}
+
+// Allow В корзине есть токен для запуска задачи
+func (b *bucket) Allow(now time.Time) bool {
+	b.Refill(now)
+	return b.Tokens >= 1
+}
+
+// Take Расход одного токена
+func (b *bucket) Take() { b.Tokens-- }
+
+// Delay Время до появления в корзине следующего токена
+func (b *bucket) Delay() time.Duration {
+	if b.Tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.Tokens) / b.Rate * float64(time.Second))
+}
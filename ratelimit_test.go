@@ -0,0 +1,110 @@
+package tasker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRateLimit Задачи запускаются не чаще указанной скорости
+func TestRateLimit(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(8).Worker(func(int) error { return nil })
+	var begin = time.Now()
+	var elapsed time.Duration
+
+	tasks.RateLimit(100, 2)
+	for i := 0; i < 12; i++ {
+		tasks.AddTask(i)
+	}
+	tasks.Run().Wait()
+	// Два запуска за счёт всплеска, остальные десять с интервалом 10ms
+	if elapsed = time.Since(begin); elapsed < time.Millisecond*90 || elapsed > time.Second {
+		t.Fatalf("Unexpected execution time: %v", elapsed)
+	}
+}
+
+// TestRateLimitBy Ограничение по ключу не задерживает задачи других ключей
+func TestRateLimitBy(t *testing.T) {
+	var tasks = NewTyped[string]().Concurrent(4)
+	var mu sync.Mutex
+	var started = make(map[string][]time.Time)
+	var slow, fast []time.Time
+
+	tasks.Worker(func(in string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		started[in] = append(started[in], time.Now())
+		return nil
+	})
+	tasks.RateLimitBy(func(in string) string { return strings.Split(in, "/")[0] }, 20, 1)
+	for i := 0; i < 4; i++ {
+		tasks.AddTask("slow.example.com/page")
+	}
+	for i := 0; i < 4; i++ {
+		tasks.AddTask("fast" + string(rune('a'+i)) + ".example.com/page")
+	}
+	tasks.Run().Wait()
+	slow = started["slow.example.com/page"]
+	for key := range started {
+		if key != "slow.example.com/page" {
+			fast = append(fast, started[key]...)
+		}
+	}
+	if len(slow) != 4 || len(fast) != 4 {
+		t.Fatalf("Unexpected started tasks: %v", started)
+	}
+	for i := 1; i < len(slow); i++ {
+		if d := slow[i].Sub(slow[i-1]); d < time.Millisecond*40 {
+			t.Fatalf("Rate limit per key exceeded: %v", d)
+		}
+	}
+	for i := range fast {
+		if fast[i].Sub(slow[0]) > time.Millisecond*40 {
+			t.Fatalf("Tasks of other keys delayed: %v", fast[i].Sub(slow[0]))
+		}
+	}
+}
+
+// TestRateLimitByWaiting Задачи ключа, исчерпавшего ограничение, отменяются и запускаются после снятия ограничения
+func TestRateLimitByWaiting(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(4)
+	var mu sync.Mutex
+	var started []int
+	var h Handle
+	var begin time.Time
+	var err error
+
+	tasks.Worker(func(in int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		started = append(started, in)
+		return nil
+	})
+	tasks.RateLimitBy(func(int) string { return "host" }, 1, 1)
+	for i := 0; i < 5; i++ {
+		if h, err = tasks.Submit(i); err != nil {
+			t.Fatalf("Submit error: %v", err)
+		}
+	}
+	begin = time.Now()
+	tasks.Run()
+	time.Sleep(time.Millisecond * 50)
+	// Отложенная ограничением скорости задача отменяется
+	if !h.Cancel() {
+		t.Fatalf("Waiting task not cancelled")
+	}
+	// Снятие ограничения возвращает отложенные задачи в очередь
+	tasks.RateLimitBy(nil, 0, 0)
+	tasks.Wait()
+	if elapsed := time.Since(begin); elapsed > time.Millisecond*500 {
+		t.Fatalf("Waiting tasks not released: %v", elapsed)
+	}
+	if len(started) != 4 {
+		t.Fatalf("Unexpected started tasks: %v", started)
+	}
+	if _, err = h.Wait(context.Background()); err != ErrTaskCancelled {
+		t.Fatalf("Unexpected cancelled task error: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"sync"
+)
+
+// resultStream Канал результатов выполнения задач с неограниченной очередью
+// Результаты не поместившиеся в буфер канала накапливаются в очереди и передаются в канал отдельной горутиной,
+// поэтому медленное чтение канала или отсутствие чтения не останавливает выполнение задач
+type resultStream[T any] struct {
+	Ch      chan TypedResult[T] // Канал результатов
+	Queue   []TypedResult[T]    // Результаты ожидающие места в буфере канала
+	Sending bool                // =true - горутина передаёт накопленные результаты в канал
+	Closed  bool                // =true - запуски закончились, канал закрывается после передачи всех результатов
+	sync.Mutex
+}
+
+// newResultStream Создание канала результатов
+func newResultStream[T any]() *resultStream[T] {
+	return &resultStream[T]{Ch: make(chan TypedResult[T], 1000)}
+}
+
+// Push Передача результата в канал без блокировки
+// Пока в очереди есть результаты, новый результат помещается в конец очереди, порядок результатов сохраняется
+func (rs *resultStream[T]) Push(rsl TypedResult[T]) {
+	rs.Lock()
+	defer rs.Unlock()
+	if rs.Closed {
+		return
+	}
+	if len(rs.Queue) == 0 && !rs.Sending {
+		select {
+		case rs.Ch <- rsl:
+			return
+		default:
+		}
+	}
+	rs.Queue = append(rs.Queue, rsl)
+	if !rs.Sending {
+		rs.Sending = true
+		go rs.Pump()
+	}
+}
+
+// Pump Передача накопленных результатов в канал, горутина
+func (rs *resultStream[T]) Pump() {
+	var rsl TypedResult[T]
+
+	for {
+		rs.Lock()
+		if len(rs.Queue) == 0 {
+			if rs.Sending = false; rs.Closed {
+				close(rs.Ch)
+			}
+			rs.Unlock()
+			return
+		}
+		rsl, rs.Queue[0] = rs.Queue[0], TypedResult[T]{}
+		rs.Queue = rs.Queue[1:]
+		rs.Unlock()
+		rs.Ch <- rsl
+	}
+}
+
+// Close Закрытие канала после передачи всех накопленных результатов
+func (rs *resultStream[T]) Close() {
+	rs.Lock()
+	defer rs.Unlock()
+	if rs.Closed {
+		return
+	}
+	if rs.Closed = true; !rs.Sending {
+		close(rs.Ch)
+	}
+}
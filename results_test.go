@@ -0,0 +1,77 @@
+package tasker
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestResults Итоговые результаты задач доступны через OnComplete и канал результатов
+func TestResults(t *testing.T) {
+	var tasks Tasker
+	var results <-chan Result
+	var completed []Result
+	var rsl Result
+	var failed, succeeded int
+
+	tasks = NewTasker().
+		Concurrent(3).
+		RetryIfError(3).
+		OnComplete(func(r Result) { completed = append(completed, r) }).
+		Worker(func(in interface{}) error {
+			if in.(int)%2 == 1 {
+				return fmt.Errorf("odd %d", in)
+			}
+			return nil
+		})
+	results = tasks.Results()
+	_ = tasks.AddTasks([]interface{}{0, 1, 2, 3, 4})
+	tasks.Run().Wait()
+	if len(completed) != 5 || len(results) != 5 {
+		t.Fatalf("Unexpected number of results: %d, %d", len(completed), len(results))
+	}
+	for len(results) > 0 {
+		rsl = <-results
+		switch rsl.Error {
+		case nil:
+			succeeded++
+			if rsl.Attempts != 1 {
+				t.Fatalf("Unexpected attempts for %v: %d", rsl.Body, rsl.Attempts)
+			}
+		default:
+			failed++
+			if rsl.Attempts != 3 || rsl.Error.Error() != fmt.Sprintf("odd %d", rsl.Body) {
+				t.Fatalf("Unexpected result for %v: %d, %v", rsl.Body, rsl.Attempts, rsl.Error)
+			}
+		}
+		if rsl.Started.IsZero() || rsl.Finished.Before(rsl.Started) {
+			t.Fatalf("Invalid timestamps for %v", rsl.Body)
+		}
+	}
+	if succeeded != 3 || failed != 2 {
+		t.Fatalf("Unexpected results: succeeded=%d, failed=%d", succeeded, failed)
+	}
+}
+
+// TestResultsUnread Не прочитанные результаты не останавливают выполнение задач, канал закрывается по завершении запуска
+func TestResultsUnread(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(4)
+	var results <-chan TypedResult[int]
+	var count int
+
+	tasks.Worker(func(int) error { return nil })
+	results = tasks.Results()
+	for i := 0; i < 2500; i++ {
+		_ = tasks.AddTask(i)
+	}
+	tasks.Run().Wait()
+	for range results {
+		count++
+	}
+	if count != 2500 {
+		t.Fatalf("Unexpected number of results: %d", count)
+	}
+	// Канал следующего запуска создаётся заново
+	if tasks.Results() == results {
+		t.Fatalf("Results channel of finished run reused")
+	}
+}
@@ -0,0 +1,108 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy Стратегия задержки перед повторным запуском задачи завершившейся ошибкой
+type RetryPolicy interface {
+	// Delay Задержка перед повтором с номером attempt, начиная с 1
+	// previous - задержка перед предыдущим повтором, для первого повтора равна 0
+	Delay(attempt int, previous time.Duration) time.Duration
+}
+
+// RetryPolicyFunc Функция реализующая интерфейс RetryPolicy
+type RetryPolicyFunc func(attempt int, previous time.Duration) time.Duration
+
+// Delay Реализация интерфейса RetryPolicy
+func (fn RetryPolicyFunc) Delay(attempt int, previous time.Duration) time.Duration {
+	return fn(attempt, previous)
+}
+
+// ConstantBackoff Постоянная задержка перед каждым повтором
+func ConstantBackoff(d time.Duration) RetryPolicy {
+	return RetryPolicyFunc(func(int, time.Duration) time.Duration { return d })
+}
+
+// LinearBackoff Задержка растущая линейно: initial, initial+step, initial+2*step...
+func LinearBackoff(initial time.Duration, step time.Duration) RetryPolicy {
+	return RetryPolicyFunc(func(attempt int, _ time.Duration) time.Duration {
+		return initial + step*time.Duration(attempt-1)
+	})
+}
+
+// ExponentialBackoff Задержка растущая экспоненциально: initial*factor^(attempt-1)
+// jitter - доля задержки от 0 до 1 на которую задержка случайно уменьшается, чтобы разнести повторы разных задач
+func ExponentialBackoff(initial time.Duration, factor float64, jitter float64) RetryPolicy {
+	return RetryPolicyFunc(func(attempt int, _ time.Duration) (ret time.Duration) {
+		var delay = float64(initial) * math.Pow(factor, float64(attempt-1))
+
+		// Значение math.MaxInt64 в float64 равно 2^63 и не помещается в time.Duration,
+		// поэтому задержка не меньше этого значения заменяется максимальной задержкой
+		if delay > math.MaxInt64 {
+			delay = math.MaxInt64
+		}
+		if jitter > 0 {
+			delay -= delay * math.Min(jitter, 1) * rand.Float64()
+		}
+		if delay >= math.MaxInt64 {
+			ret = time.Duration(math.MaxInt64)
+			return
+		}
+		ret = time.Duration(delay)
+		return
+	})
+}
+
+// DecorrelatedJitter Задержка выбирается случайно в диапазоне от base до утроенной предыдущей задержки
+// Стратегия "decorrelated jitter", задержка должна быть ограничена с помощью MaxDelay
+func DecorrelatedJitter(base time.Duration) RetryPolicy {
+	return RetryPolicyFunc(func(_ int, previous time.Duration) (ret time.Duration) {
+		var upper = previous * 3
+
+		if upper <= base || upper < previous {
+			return base
+		}
+		ret = base + time.Duration(rand.Int63n(int64(upper-base)))
+		return
+	})
+}
+
+// MaxDelay Ограничение задержки стратегии сверху
+func MaxDelay(p RetryPolicy, max time.Duration) RetryPolicy {
+	return RetryPolicyFunc(func(attempt int, previous time.Duration) (ret time.Duration) {
+		if ret = p.Delay(attempt, previous); ret > max || ret < 0 {
+			ret = max
+		}
+		return
+	})
+}
+
+// Permanent Ошибка после которой задача не повторяется, даже если попытки выполнения не исчерпаны
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent Проверка является ли ошибка постоянной
+func IsPermanent(err error) bool {
+	var perr *permanentError
+	return errors.As(err, &perr)
+}
+
+type permanentError struct {
+	err error
+}
+
+// Error Реализация интерфейса error
+func (e *permanentError) Error() string { return e.err.Error() }
+
+// Unwrap Исходная ошибка
+func (e *permanentError) Unwrap() error { return e.err }
@@ -0,0 +1,103 @@
+package tasker
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestRetryPolicies Проверка задержек встроенных стратегий
+func TestRetryPolicies(t *testing.T) {
+	var d time.Duration
+
+	if d = ConstantBackoff(time.Second).Delay(5, 0); d != time.Second {
+		t.Fatalf("ConstantBackoff: %s", d)
+	}
+	if d = LinearBackoff(time.Second, time.Second/2).Delay(3, 0); d != 2*time.Second {
+		t.Fatalf("LinearBackoff: %s", d)
+	}
+	if d = ExponentialBackoff(time.Second, 2, 0).Delay(4, 0); d != 8*time.Second {
+		t.Fatalf("ExponentialBackoff: %s", d)
+	}
+	if d = ExponentialBackoff(time.Second, 2, 0).Delay(40, 0); d != time.Duration(math.MaxInt64) {
+		t.Fatalf("ExponentialBackoff overflow: %s", d)
+	}
+	for i := 0; i < 100; i++ {
+		if d = ExponentialBackoff(time.Second, 2, 0.5).Delay(100, 0); d < time.Duration(math.MaxInt64/2) {
+			t.Fatalf("ExponentialBackoff overflow with jitter: %s", d)
+		}
+		if d = ExponentialBackoff(time.Second, 2, 0.5).Delay(4, 0); d < 4*time.Second || d > 8*time.Second {
+			t.Fatalf("ExponentialBackoff with jitter: %s", d)
+		}
+		if d = DecorrelatedJitter(time.Second).Delay(2, 2*time.Second); d < time.Second || d >= 6*time.Second {
+			t.Fatalf("DecorrelatedJitter: %s", d)
+		}
+	}
+	if d = DecorrelatedJitter(time.Second).Delay(1, 0); d != time.Second {
+		t.Fatalf("DecorrelatedJitter first retry: %s", d)
+	}
+	if d = MaxDelay(ExponentialBackoff(time.Second, 10, 0), time.Minute).Delay(100, 0); d != time.Minute {
+		t.Fatalf("MaxDelay: %s", d)
+	}
+}
+
+// TestRetryBackoff Повтор задачи выполняется не раньше задержки стратегии
+func TestRetryBackoff(t *testing.T) {
+	var tasks Tasker
+	var starts []time.Time
+
+	tasks = NewTasker().
+		Concurrent(1).
+		RetryIfError(3).
+		Backoff(ConstantBackoff(time.Second / 10)).
+		Worker(func(in interface{}) error {
+			starts = append(starts, time.Now())
+			return fmt.Errorf("Failed")
+		})
+	_ = tasks.AddTask(1)
+	tasks.Run().Wait()
+	if len(starts) != 3 {
+		t.Fatalf("Unexpected number of attempts: %d", len(starts))
+	}
+	for i := 1; i < len(starts); i++ {
+		if starts[i].Sub(starts[i-1]) < time.Second/10 {
+			t.Fatalf("Retry started before backoff delay: %s", starts[i].Sub(starts[i-1]))
+		}
+	}
+}
+
+// TestRetryPermanent Постоянные ошибки и ошибки отвергнутые RetryIf не повторяются
+func TestRetryPermanent(t *testing.T) {
+	var tasks Tasker
+	var calls = make(map[string]int)
+	var errSkip = errors.New("Skip")
+	var dead []DeadLetter
+
+	tasks = NewTasker().
+		Concurrent(1).
+		RetryIfError(5).
+		RetryIf(func(err error) bool { return !errors.Is(err, errSkip) }).
+		Worker(func(in interface{}) error {
+			calls[in.(string)]++
+			switch in.(string) {
+			case "permanent":
+				return Permanent(fmt.Errorf("Broken"))
+			case "skip":
+				return errSkip
+			}
+			return fmt.Errorf("Temporary")
+		})
+	_ = tasks.AddTasks([]interface{}{"permanent", "skip", "temporary"})
+	tasks.Run().Wait()
+	if calls["permanent"] != 1 || calls["skip"] != 1 || calls["temporary"] != 5 {
+		t.Fatalf("Unexpected number of calls: %v", calls)
+	}
+	if dead = tasks.DeadLetters(); len(dead) != 3 {
+		t.Fatalf("Unexpected number of dead letters: %d", len(dead))
+	}
+	if err := dead[0].Attempts[0].Error; !IsPermanent(err) || err.Error() != "Broken" {
+		t.Fatalf("Unexpected permanent error: %v", err)
+	}
+}
@@ -3,73 +3,112 @@ package tasker
 //import "gopkg.in/webnice/log.v2"
 //import "gopkg.in/webnice/debug.v1"
 import (
-	"container/list"
+	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // Run Запуск выполнения задач без ожидания
 // Функция возвращает выполнение после запуска контроллера задач
-func (tsk *implementation) Run() Tasker {
+func (tsk *implementation[T]) Run() Typed[T] { return tsk.RunContext(context.Background()) }
+
+// RunContext Запуск выполнения задач без ожидания с родительским контекстом
+// Отмена родительского контекста равносильна вызову Interrupt() и отменяет контексты выполняющихся задач
+func (tsk *implementation[T]) RunContext(ctx context.Context) Typed[T] {
 	tsk.Lock()
 	defer tsk.Unlock()
+	tsk.Start(ctx)
+	return tsk
+}
 
+// Start Запуск работников и менеджера, вызывается под блокировкой таскера
+// Ошибка запуска сохраняется в Err, =true - таскер запущен
+func (tsk *implementation[T]) Start(ctx context.Context) bool {
 	var i int
 
 	tsk.Err = tsk.CanRun()
 	if tsk.Err != nil {
-		return tsk
+		return false
+	}
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	tsk.Ctx, tsk.Cancel = context.WithCancel(ctx)
 
 	// Предварительная обработка всех задач функцией BootstrapFunc
 	// Если при первом запуске будет ошибка то ничего не стартует
 	// В случае ошибки при запуске в ходе работы, будет прерывание
 	if tsk.PreludeTasks(); tsk.Err != nil {
-		return tsk
+		tsk.Cancel()
+		return false
 	}
 
-	tsk.isWork = true
+	tsk.isWork, tsk.Lenient = true, false
+	tsk.Runs++
 
-	// Создание работников
+	// Канал задач и работники создаются на каждый запуск, работники предыдущего запуска
+	// могут ещё завершаться, когда таскер уже запущен повторно
+	// Размер канала не меньше количества работников, поэтому отправка задачи менеджером не блокируется,
+	// пока количество работников не увеличено во время работы сверх размера канала
+	tsk.ChanIn = make(chan *task[T], max(tsk.ConcurrentProcesses, tsk.ScaleMax))
+	tsk.WorkerSeq, tsk.WorkerPool, tsk.Retired = 0, nil, nil
 	for i = 0; i < tsk.ConcurrentProcesses; i++ {
-		tsk.WorkerPool = append(tsk.WorkerPool, worker{
-			ID:       i,
-			Parent:   tsk,
-			Shutdown: make(chan interface{}, 1),
-			Done:     make(chan interface{}, 1),
-		})
+		tsk.Spawn()
 	}
 
-	// Запуск всех работников
-	for i = range tsk.WorkerPool {
-		tsk.WorkerWG.Add(1)
-		go func(wg *sync.WaitGroup, w *worker) {
-			defer wg.Done()
-			w.Do()
-		}(&tsk.WorkerWG, &tsk.WorkerPool[i])
+	// Сигнал прерывания, отправленный предыдущему запуску после того как его менеджер завершился
+	// по отмене контекста, не должен прервать новый запуск
+	select {
+	case <-tsk.ChanInterrupt:
+	default:
 	}
 
 	// Запуск менеджера
 	tsk.WorkerWG.Add(1)
-	go func(wg *sync.WaitGroup) {
+	go func(wg *sync.WaitGroup, cancel context.CancelFunc, in chan *task[T]) {
+		var pool []*worker[T]
+		var lenient bool
+
 		defer wg.Done()
-		defer func() { tsk.isWork = false }()
-		tsk.Manager()
-		// Отправка всем сигнала завершения
-		for i := range tsk.WorkerPool {
-			tsk.WorkerPool[i].Shutdown <- true
+		pool = tsk.Manager()
+		// Отмена контекстов задач, которые ещё выполняются после прерывания, кроме остановки в режиме ShutdownFinish
+		tsk.Lock()
+		lenient, tsk.Lenient = tsk.Lenient, false
+		tsk.Unlock()
+		if !lenient {
+			cancel()
+		}
+		// Отправка всем сигнала завершения, выведенные из работы работники сигнал уже получили
+		for i := range pool {
+			select {
+			case pool[i].Shutdown <- true:
+			default:
+			}
 		}
 		// Ждём от всех ответ о завершении
-		for i := range tsk.WorkerPool {
-			<-tsk.WorkerPool[i].Done
+		for i := range pool {
+			<-pool[i].Done
 		}
-	}(&tsk.WorkerWG)
+		// Возврат в очередь не начатых задач и обработка результатов задач завершившихся после остановки менеджера
+		tsk.Reclaim(in)
+		// Все задачи запуска завершены, контекст запуска освобождается и в режиме ShutdownFinish
+		cancel()
+		// Канал результатов закрывается, когда завершены все запуски
+		tsk.Lock()
+		if tsk.Runs--; tsk.Runs == 0 && tsk.Stream != nil {
+			tsk.Stream.Close()
+			tsk.Stream = nil
+		}
+		tsk.Unlock()
+	}(&tsk.WorkerWG, tsk.Cancel, tsk.ChanIn)
 
-	return tsk
+	return true
 }
 
 // CanRun Проверка возможности запуска таскера
-func (tsk *implementation) CanRun() (err error) {
+func (tsk *implementation[T]) CanRun() (err error) {
 	// Количество паралельных процессов долно быть больше 0
 	if tsk.ConcurrentProcesses <= 0 {
 		err = fmt.Errorf("An invalid value in parallel running processes: %d", tsk.ConcurrentProcesses)
@@ -92,101 +131,366 @@ func (tsk *implementation) CanRun() (err error) {
 }
 
 // Manager Процесс поставки данных работникам, получения и обработки результатов
-// Manager завершается когда кончились задачи, за собой гасит всех работников
-func (tsk *implementation) Manager() {
-	var err error
-	var r *result
+// Manager завершается когда кончились задачи и возвращает всех работников запуска, которых необходимо погасить
+// Блокировка таскера захватывается только на время обработки события, в ожидании событий Manager не потребляет процессор
+func (tsk *implementation[T]) Manager() (pool []*worker[T]) {
 	var interrupt bool
-
-	tsk.Lock()
-	defer tsk.Unlock()
+	var timer *time.Timer
+	var wakeup <-chan time.Time
+	var next time.Time
 
 	for {
-		// Предварительная обработка всех задач функцией BootstrapFunc
+		tsk.Lock()
+		// Предварительная обработка новых задач функцией BootstrapFunc
 		if tsk.PreludeTasks(); tsk.Err != nil {
 			interrupt = true
 		}
-
-		// Задачи кончились и в исходящем канале пусто, можно выходить (gocyclo > 10) fuck!
-		if tsk.CanExit(interrupt, err) {
-			break
+		if !interrupt && !tsk.Paused {
+			tsk.Dispatch()
+			tsk.Rescale(time.Now())
+		}
+		// Задачи кончились и нет выполняющихся задач, можно выходить
+		// Таскер считается остановленным с момента завершения менеджера, задачи добавленные
+		// после этого момента будут выполнены при следующем запуске
+		if tsk.CanExit(interrupt) {
+			tsk.isWork = false
+			pool = append(tsk.WorkerPool, tsk.Retired...)
+			tsk.WorkerPool, tsk.Retired = nil, nil
+			tsk.Unlock()
+			tsk.Deliver()
+			return
 		}
+		// Ближайшее из времени запуска отложенной задачи, времени снятия ограничения скорости и времени оценки количества работников
+		next = tsk.Scheduled.Next()
+		for _, at := range []time.Time{tsk.Throttled, tsk.Rescaling()} {
+			if !at.IsZero() && (next.IsZero() || at.Before(next)) {
+				next = at
+			}
+		}
+		tsk.Unlock()
+		tsk.Deliver()
 
+		// Ожидание события: добавление задач, результат работника, прерывание, наступление времени отложенной задачи или снятия ограничения скорости
+		if wakeup = nil; !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			wakeup = timer.C
+		}
 		select {
 		case <-tsk.ChanInterrupt:
 			interrupt = true
-		case r = <-tsk.ChanOut:
+		case <-tsk.Ctx.Done():
+			interrupt = true
+		case r := <-tsk.ChanOut:
+			tsk.Lock()
 			tsk.TaskResult(r)
-		default:
-			// Отправка одной задачи в канал, если он заполнен меньше чем на количество паралельных процессов
-			if len(tsk.ChanIn) < tsk.ConcurrentProcesses {
-				err = tsk.PushNextTask()
-			}
+			tsk.Unlock()
+		case <-tsk.Wake:
+		case <-wakeup:
+		}
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+	}
+}
+
+// Dispatch Отправка работникам готовых к запуску задач, пока есть свободные работники
+func (tsk *implementation[T]) Dispatch() {
+	tsk.Throttled = time.Time{}
+	tsk.Unthrottle(time.Now())
+	for tsk.InFlight < tsk.ConcurrentProcesses {
+		if tsk.PushNextTask() != nil {
+			return
 		}
 	}
 }
 
 // CanExit Определяем можно ли выйти
-func (tsk *implementation) CanExit(interrupt bool, err error) (ret bool) {
-	if len(tsk.ChanIn) == 0 && len(tsk.ChanOut) == 0 && tsk.Tasks.Len() == 0 && err != nil || interrupt {
+func (tsk *implementation[T]) CanExit(interrupt bool) (ret bool) {
+	if tsk.Tasks.Len() == 0 && tsk.InFlight == 0 && tsk.Sources == 0 || interrupt {
 		ret = true
 	}
 	return
 }
 
+// Reclaim Возврат в очередь задач оставшихся в канале остановленных работников
+// и обработка результатов задач, которые работники завершили после остановки менеджера
+func (tsk *implementation[T]) Reclaim(in chan *task[T]) {
+	var item *task[T]
+
+	tsk.Lock()
+	for len(in) > 0 {
+		item = <-in
+		tsk.InFlight--
+		tsk.Release(item)
+		item.Lock()
+		item.InWork = false
+		cancelled := item.Cancelled
+		item.Unlock()
+		switch {
+		case tsk.Pending[item.ID] != item:
+		case cancelled:
+			tsk.Discard(item, ErrTaskCancelled)
+		default:
+			tsk.Nack(item)
+			tsk.Enqueue(item)
+		}
+	}
+	for len(tsk.ChanOut) > 0 {
+		tsk.TaskResult(<-tsk.ChanOut)
+	}
+	tsk.Unlock()
+	tsk.Signal()
+	tsk.Deliver()
+}
+
+// Signal Сигнал менеджеру о появлении новых задач или изменении настроек
+func (tsk *implementation[T]) Signal() {
+	select {
+	case tsk.Wake <- struct{}{}:
+	default:
+	}
+}
+
 // TaskResult Обработка результата
-func (tsk *implementation) TaskResult(r *result) {
-	var elm *list.Element
-	var item *task
+func (tsk *implementation[T]) TaskResult(r *result[T]) {
+	var item = r.Task
+	var cancelled, aborted bool
 
-	// Поиск
-	for elm = tsk.Tasks.Front(); elm != nil; elm = elm.Next() {
-		if elm.Value.(*task) != r.Task {
-			continue
+	// Задача могла быть удалена из очереди пока выполнялась
+	tsk.Release(item)
+	tsk.Measure(r)
+	if tsk.InFlight--; tsk.Pending[item.ID] != item {
+		return
+	}
+	item.Lock()
+	cancelled = item.Cancelled
+	item.Unlock()
+	// Ошибка отменённой задачи заменяется на ErrTaskCancelled, отменённая задача не повторяется
+	if r.Error != nil && cancelled {
+		r.Error = ErrTaskCancelled
+	}
+	if aborted = r.Aborted && !cancelled; r.Error != nil && !aborted {
+		item.CountError++
+	}
+	item.Value = r.Value
+	item.History = append(item.History, Attempt{
+		Started:  r.Started,
+		Finished: r.Finished,
+		WorkerID: r.WorkerID,
+		Error:    r.Error,
+	})
+	// Попытка прерванная отменой контекста запуска не является ошибкой задачи,
+	// задача остаётся в очереди и будет выполнена при следующем запуске
+	if aborted {
+		item.Lock()
+		item.InWork = false
+		item.Status = StatusQueued
+		item.Unlock()
+		tsk.Nack(item)
+		tsk.Enqueue(item)
+		return
+	}
+	if r.Error != nil && !cancelled && tsk.RetryCount > item.CountError && tsk.IsRetryable(r.Error) {
+		item.Lock()
+		item.InWork = false
+		item.Status = StatusRetrying
+		if tsk.RetryPolicy != nil {
+			item.Delay = tsk.RetryPolicy.Delay(item.CountError, item.Delay)
+			item.NotBefore = r.Finished.Add(item.Delay)
 		}
-		if r.Error != nil && tsk.RetryCount > r.Task.CountError {
-			item = elm.Value.(*task)
-			item.Lock()
-			item.InWork = false
-			item.Unlock()
-			continue
+		item.Unlock()
+		tsk.Nack(item)
+		tsk.Enqueue(item)
+		return
+	}
+	tsk.Tasks.Remove(item.Element)
+	item.Element = nil
+	if item.Err = r.Error; r.Error != nil && !cancelled {
+		item.Failed = r.Finished
+		tsk.DeadTasks.PushBack(item)
+	}
+	tsk.Resolve(item, r.Error)
+	tsk.Complete(r)
+}
+
+// IsRetryable Можно ли повторить задачу завершившуюся ошибкой
+func (tsk *implementation[T]) IsRetryable(err error) (ret bool) {
+	defer func() {
+		if e := recover(); e != nil {
+			ret = false
 		}
-		tsk.Tasks.Remove(elm)
+	}()
+	if IsPermanent(err) {
+		return
 	}
+	ret = tsk.RetryableFn == nil || tsk.RetryableFn(err)
+	return
 }
 
-// PreludeTasks Выполнение над задачами функции BootstrapFunc, если такая установлена
-func (tsk *implementation) PreludeTasks() {
-	var elm *list.Element
-	var item *task
-	var items []*task
-	var i int
+// Complete Подготовка итогового результата выполнения задачи к передаче в OnComplete и в канал результатов
+// В канал результатов результат передаётся сразу без блокировки, в OnComplete - функцией Deliver без захвата блокировки таскера
+func (tsk *implementation[T]) Complete(r *result[T]) {
+	var rsl = TypedResult[T]{
+		ID:       r.Task.ID,
+		Body:     r.Task.Body,
+		Error:    r.Error,
+		Value:    r.Task.Value,
+		Attempts: r.Task.Attempts,
+		Started:  r.Task.Started,
+		Finished: r.Finished,
+		WorkerID: r.WorkerID,
+	}
+
+	r.Task.Lock()
+	switch r.Task.Outcome = rsl.Untyped(); {
+	case r.Error == nil:
+		r.Task.Status = StatusSucceeded
+	case errors.Is(r.Error, ErrTaskCancelled):
+		r.Task.Status = StatusCancelled
+	default:
+		r.Task.Status = StatusFailed
+	}
+	r.Task.Unlock()
+	r.Task.Finish()
+	tsk.Unclaim(r.Task, r.Error)
+	tsk.Ack(r.Task)
+	tsk.Record(rsl)
+	tsk.Vacate()
+	if tsk.Stream != nil {
+		tsk.Stream.Push(rsl)
+	}
+	tsk.Outbox = append(tsk.Outbox, outgoing[T]{Result: rsl, Notify: r.Task.Notify})
+}
+
+// Deliver Передача накопленных итоговых результатов в OnComplete
+// Вызывается без захвата блокировки, поэтому OnComplete может вызывать методы таскера
+func (tsk *implementation[T]) Deliver() {
+	var outbox []outgoing[T]
+	var fn func(TypedResult[T])
+
+	tsk.Lock()
+	outbox, fn = tsk.Outbox, tsk.OnCompleteFn
+	tsk.Outbox = nil
+	tsk.Unlock()
+	for i := range outbox {
+		if outbox[i].Notify != nil {
+			outbox[i].Notify(outbox[i].Result.Untyped())
+		}
+		if fn != nil {
+			tsk.SafeCallOnComplete(fn, outbox[i].Result)
+		}
+	}
+}
+
+// SafeCallOnComplete Безопасный запуск внешней функции OnComplete
+func (tsk *implementation[T]) SafeCallOnComplete(fn func(TypedResult[T]), rsl TypedResult[T]) {
+	defer func() {
+		if e := recover(); e != nil {
+			tsk.Lock()
+			tsk.Err = fmt.Errorf("Recovery panic call external OnComplete: %v", e)
+			tsk.Unlock()
+		}
+	}()
+	fn(rsl)
+}
+
+// PreludeTasks Выполнение над новыми задачами функции BootstrapFunc, если такая установлена
+// Новые задачи приостановленного таскера обрабатываются после возобновления
+// Вызывается под блокировкой таскера, на работающем таскере блокировка освобождается на время вызова BootstrapFunc
+func (tsk *implementation[T]) PreludeTasks() {
+	var items []*task[T]
+	var size, i int
+	var err error
+	var working bool
+	var ctx, fn = tsk.Ctx, tsk.BootstrapFn
 
-	for elm = tsk.Tasks.Front(); elm != nil; elm = elm.Next() {
-		if elm.Value.(*task).Prelude == false {
-			item = elm.Value.(*task)
-			item.Lock()
-			item.InWork = true
-			item.Prelude = true
-			item.Unlock()
-			items = append(items, item)
+	if tsk.Paused {
+		return
+	}
+	for i = range tsk.Fresh {
+		if tsk.Pending[tsk.Fresh[i].ID] != tsk.Fresh[i] || tsk.Fresh[i].Prelude {
+			continue
 		}
+		tsk.Fresh[i].Lock()
+		tsk.Fresh[i].InWork = true
+		tsk.Fresh[i].Prelude = true
+		tsk.Fresh[i].Status = StatusBootstrapping
+		tsk.Fresh[i].Unlock()
+		items = append(items, tsk.Fresh[i])
 	}
+	tsk.Fresh = tsk.Fresh[:0]
 	if len(items) == 0 {
 		return
 	}
-	tsk.Err = tsk.SafeCallBootstrapFunc(items)
+	// Задачи передаются в BootstrapFunc пакетами не больше BatchSize, после ошибки обработка прекращается
+	if size = tsk.Batch; size <= 0 {
+		size = len(items)
+	}
+	// На работающем таскере блокировка на время вызова BootstrapFunc освобождается, поэтому добавление задач
+	// и другие методы таскера не ждут окончания обработки, а BootstrapFunc может вызывать методы таскера
+	if working = tsk.isWork; working {
+		tsk.Unlock()
+	}
+	for i = 0; i < len(items) && err == nil; i += size {
+		err = tsk.SafeCallBootstrapFunc(fn, ctx, items[i:min(i+size, len(items))])
+	}
+	if working {
+		tsk.Lock()
+	}
+	tsk.Err = err
 	for i = range items {
 		items[i].Lock()
 		items[i].InWork = false
 		items[i].Prelude = true
+		items[i].Status = StatusQueued
+		cancelled := items[i].Cancelled
 		items[i].Unlock()
+		// Задача могла быть удалена или отменена во время обработки
+		switch {
+		case tsk.Pending[items[i].ID] != items[i]:
+			continue
+		case cancelled:
+			tsk.Discard(items[i], ErrTaskCancelled)
+			continue
+		}
+		// Отметка об обработке сохраняется, чтобы восстановленная из хранилища задача не обрабатывалась повторно
+		tsk.Nack(items[i])
+		tsk.Enqueue(items[i])
+	}
+}
+
+// Enqueue Помещение задачи в очередь готовых к запуску задач или в список ожидающих задержки перед повтором
+// Задачи ожидающие выполнения зависимостей или предыдущей задачи своего ключа помещаются в очередь по мере их выполнения
+func (tsk *implementation[T]) Enqueue(item *task[T]) {
+	if item.Waiting > 0 || item.Behind() {
+		return
+	}
+	// Задача не запускается повторно, пока выполняется брошенный вызов функции обработки с тем же объектом задачи
+	if item.Stray {
+		item.Held = true
+		return
+	}
+	if item.NotBefore.After(time.Now()) {
+		tsk.Scheduled.Add(item)
+		return
+	}
+	tsk.Ready.Add(item)
+}
+
+// PromoteScheduled Перемещение задач, время запуска которых наступило, в очередь готовых к запуску задач
+func (tsk *implementation[T]) PromoteScheduled() {
+	var item *task[T]
+	var now = time.Now()
+
+	for item = tsk.Scheduled.Due(now); item != nil; item = tsk.Scheduled.Due(now) {
+		tsk.Ready.Add(item)
 	}
 }
 
 // SafeCallBootstrapFunc Безопасный запуск внешней функции
-func (tsk *implementation) SafeCallBootstrapFunc(items []*task) (err error) {
-	var data []interface{}
+func (tsk *implementation[T]) SafeCallBootstrapFunc(fn TypedBootstrapCtxFunc[T], ctx context.Context, items []*task[T]) (err error) {
+	var data []T
 	var i int
 
 	defer func() {
@@ -199,37 +503,43 @@ func (tsk *implementation) SafeCallBootstrapFunc(items []*task) (err error) {
 	for i = range items {
 		data = append(data, items[i].Body)
 	}
-	if tsk.BootstrapFn != nil {
-		err = tsk.BootstrapFn(data)
+	if fn != nil {
+		err = fn(ctx, data)
 	}
 	return
 }
 
-// PushNextTask Получение первой свободной задачи, если результат nil, задач больше нет
-func (tsk *implementation) PushNextTask() (err error) {
-	var elm *list.Element
-	var item *task
+// PushNextTask Отправка работникам задачи с наибольшим приоритетом, если задач готовых к запуску нет, возвращается ошибка
+func (tsk *implementation[T]) PushNextTask() (err error) {
+	var item *task[T]
+	var now = time.Now()
 
-	for elm = tsk.Tasks.Front(); elm != nil; elm = elm.Next() {
-		if !elm.Value.(*task).InWork && elm.Value.(*task).Prelude {
-			item = elm.Value.(*task)
-			item.Lock()
-			item.InWork = true
-			item.Unlock()
-			break
-		}
+	tsk.PromoteScheduled()
+	if tsk.Limit != nil && tsk.Ready.Len() > 0 && !tsk.Limit.Allow(now) {
+		tsk.Throttle(now.Add(tsk.Limit.Delay()))
+		err = fmt.Errorf("Rate limit exceeded")
+		return
 	}
-	if item == nil {
+	if item = tsk.Ready.NextFunc(func(t *task[T]) bool { return tsk.Eligible(t, now) }); item == nil {
 		err = fmt.Errorf("No new task")
 		return
 	}
+	tsk.Acquire(item, now)
+	item.Lock()
+	item.InWork = true
+	item.Unlock()
+	tsk.InFlight++
+	tsk.Lease(item)
 	tsk.ChanIn <- item
+	tsk.Vacate()
 
 	return
 }
 
 // IsWork Текущее состояние выполнения задач
 // =true - tasker выполняет задачи, =false - tasker закончил выполнение всех задач, все goroutines навершены
-func (tsk *implementation) IsWork() bool {
+func (tsk *implementation[T]) IsWork() bool {
+	tsk.Lock()
+	defer tsk.Unlock()
 	return tsk.isWork
 }
@@ -0,0 +1,104 @@
+package tasker
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAddWhileRunning Добавление задач и чтение состояния не блокируются работающим менеджером
+func TestAddWhileRunning(t *testing.T) {
+	var tasks Tasker
+	var started = make(chan interface{}, 10)
+	var release = make(chan interface{})
+	var added = make(chan error, 1)
+	var stats Stats
+
+	tasks = NewTasker().
+		Concurrent(1).
+		Worker(func(in interface{}) error {
+			started <- in
+			<-release
+			return nil
+		})
+	_ = tasks.AddTask(1)
+	tasks.Run()
+	<-started
+	go func() { added <- tasks.AddTask(2) }()
+	select {
+	case err := <-added:
+		if err != nil {
+			t.Fatalf("Error add task: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("AddTask blocked by running tasker")
+	}
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if stats = tasks.Stats(); stats.InWork == 1 && stats.Ready == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Unexpected stats: %+v", stats)
+		}
+	}
+	close(release)
+	tasks.Wait()
+	if len(started) != 1 || tasks.GetTasksNumber() != 0 {
+		t.Fatalf("Task added while running was not executed")
+	}
+}
+
+// TestBootstrapWhileRunning BootstrapFunc новых задач работающего таскера не блокирует методы таскера и может их вызывать
+func TestBootstrapWhileRunning(t *testing.T) {
+	var tasks Tasker
+	var started = make(chan interface{}, 10)
+	var release = make(chan interface{})
+	var booting = make(chan interface{}, 10)
+	var resume = make(chan interface{})
+	var added = make(chan error, 1)
+
+	tasks = NewTasker().
+		Concurrent(1).
+		Bootstrap(func(in []interface{}) error {
+			for i := range in {
+				if in[i].(int) == 2 {
+					booting <- true
+					<-resume
+					return tasks.AddTask(3)
+				}
+			}
+			return nil
+		}).
+		Worker(func(in interface{}) error {
+			started <- in
+			if in.(int) == 1 {
+				<-release
+			}
+			return nil
+		})
+	_ = tasks.AddTask(1)
+	tasks.Run()
+	<-started
+	_ = tasks.AddTask(2)
+	<-booting
+	go func() { added <- tasks.AddTask(4) }()
+	select {
+	case err := <-added:
+		if err != nil {
+			t.Fatalf("Error add task: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("AddTask blocked by running BootstrapFunc")
+	}
+	if stats := tasks.Stats(); stats.InWork != 2 || stats.New != 1 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	close(resume)
+	close(release)
+	tasks.Wait()
+	if err := tasks.Error(); err != nil {
+		t.Fatalf("Error run tasker: %v", err)
+	}
+	if len(started) != 3 || tasks.GetTasksNumber() != 0 {
+		t.Fatalf("Unexpected number of executed tasks: %d, left %d", len(started), tasks.GetTasksNumber())
+	}
+}
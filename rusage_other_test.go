@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package tasker
+
+import "time"
+
+// cpuTime Процессорное время потреблённое процессом, на платформе не поддерживается
+func cpuTime() time.Duration { return -1 }
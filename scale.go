@@ -0,0 +1,140 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"time"
+)
+
+// Интервал между оценками необходимого количества работников при автоматическом изменении
+const autoscaleInterval = time.Second / 10
+
+// Autoscale Автоматическое изменение количества работников между min и max
+// Количество работников увеличивается, если все работники заняты и в очереди есть готовые к запуску задачи, которые при
+// текущем среднем времени выполнения задачи не будут разобраны за интервал оценки, и уменьшается вдвое от количества
+// простаивающих работников, если очередь пуста. Значение max <= 0 или max < min выключает автоматическое изменение
+func (tsk *implementation[T]) Autoscale(min, max int) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	if min < 1 {
+		min = 1
+	}
+	if tsk.ScaleMin, tsk.ScaleMax = 0, 0; max <= 0 || max < min {
+		return tsk
+	}
+	tsk.ScaleMin, tsk.ScaleMax = min, max
+	tsk.Scale(tsk.ConcurrentProcesses)
+	return tsk
+}
+
+// Spawn Запуск нового работника текущего запуска, вызывается под блокировкой таскера
+func (tsk *implementation[T]) Spawn() {
+	var w = &worker[T]{
+		ID:       tsk.WorkerSeq,
+		Parent:   tsk,
+		In:       tsk.ChanIn,
+		Shutdown: make(chan interface{}, 1),
+		Done:     make(chan interface{}, 1),
+		Ctx:      tsk.Ctx,
+		Timeout:  tsk.TaskTimeout,
+		Fn:       tsk.WorkerFn,
+	}
+
+	tsk.WorkerSeq++
+	tsk.WorkerPool = append(tsk.WorkerPool, w)
+	tsk.WorkerWG.Add(1)
+	go func() {
+		defer tsk.WorkerWG.Done()
+		w.Do()
+	}()
+}
+
+// Resize Приведение количества работников текущего запуска к значению ConcurrentProcesses
+// Лишние работники получают сигнал завершения и завершаются после выполнения текущей задачи
+func (tsk *implementation[T]) Resize() {
+	var w *worker[T]
+	var n int
+
+	// Завершившиеся выведенные из работы работники больше не нужны для ожидания при остановке
+	for _, w = range tsk.Retired {
+		select {
+		case <-w.Done:
+		default:
+			tsk.Retired[n] = w
+			n++
+		}
+	}
+	tsk.Retired = tsk.Retired[:n]
+	for len(tsk.WorkerPool) < tsk.ConcurrentProcesses {
+		tsk.Spawn()
+	}
+	for n = len(tsk.WorkerPool) - 1; n >= tsk.ConcurrentProcesses; n-- {
+		w = tsk.WorkerPool[n]
+		tsk.WorkerPool[n] = nil
+		tsk.WorkerPool = tsk.WorkerPool[:n]
+		w.Shutdown <- true
+		tsk.Retired = append(tsk.Retired, w)
+	}
+	tsk.Signal()
+}
+
+// Scale Установка количества работников в пределах автоматического изменения, вызывается под блокировкой таскера
+func (tsk *implementation[T]) Scale(n int) {
+	if tsk.ScaleMax > 0 {
+		n = min(max(n, tsk.ScaleMin), tsk.ScaleMax)
+	}
+	if n == tsk.ConcurrentProcesses {
+		return
+	}
+	if tsk.ConcurrentProcesses = n; tsk.isWork {
+		tsk.Resize()
+	}
+}
+
+// Rescale Оценка необходимого количества работников по длине очереди и времени выполнения задач
+func (tsk *implementation[T]) Rescale(now time.Time) {
+	var n = tsk.ConcurrentProcesses
+	var backlog = tsk.Ready.Len()
+	var need int
+
+	if tsk.ScaleMax == 0 || now.Sub(tsk.ScaledAt) < autoscaleInterval {
+		return
+	}
+	tsk.ScaledAt = now
+	switch {
+	case backlog > 0 && tsk.InFlight >= n:
+		// Количество работников, которое разберёт очередь за интервал оценки, пока время выполнения не известно - по работнику на задачу
+		if need = backlog; tsk.Latency > 0 {
+			need = int((time.Duration(backlog)*tsk.Latency + autoscaleInterval - 1) / autoscaleInterval)
+		}
+		// За одну оценку количество работников увеличивается не более чем вдвое
+		if need > n {
+			tsk.Scale(min(need, n*2))
+		}
+	case backlog == 0 && tsk.InFlight < n:
+		tsk.Scale(n - (n-tsk.InFlight+1)/2)
+	}
+}
+
+// Rescaling Время следующей оценки количества работников, нулевое время если оценка не требуется
+// Оценка требуется пока есть ожидающие запуска задачи или простаивающие работники сверх минимума
+func (tsk *implementation[T]) Rescaling() (ret time.Time) {
+	if tsk.ScaleMax == 0 || tsk.Paused {
+		return
+	}
+	if tsk.Ready.Len() > 0 || tsk.ConcurrentProcesses > max(tsk.InFlight, tsk.ScaleMin) {
+		ret = tsk.ScaledAt.Add(autoscaleInterval)
+	}
+	return
+}
+
+// Measure Учёт времени выполнения задачи в скользящем среднем
+func (tsk *implementation[T]) Measure(r *result[T]) {
+	var d = r.Finished.Sub(r.Started)
+
+	if tsk.Latency == 0 {
+		tsk.Latency = d
+		return
+	}
+	tsk.Latency += (d - tsk.Latency) / 8
+}
@@ -0,0 +1,89 @@
+package tasker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitStats Ожидание состояния таскера с ограничением времени ожидания
+func waitStats(t *testing.T, tasks Typed[int], fn func(Stats) bool) {
+	for deadline := time.Now().Add(time.Second * 2); !fn(tasks.Stats()); {
+		if time.Now().After(deadline) {
+			t.Fatalf("Unexpected stats: %+v", tasks.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestConcurrentResize Изменение количества работников работающего таскера
+func TestConcurrentResize(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(2)
+	var release = make(chan struct{})
+	var mu sync.Mutex
+	var done int
+
+	tasks.Worker(func(int) error {
+		<-release
+		mu.Lock()
+		done++
+		mu.Unlock()
+		return nil
+	})
+	for i := 0; i < 20; i++ {
+		tasks.AddTask(i)
+	}
+	tasks.Run()
+	waitStats(t, tasks, func(s Stats) bool { return s.InWork == 2 && s.Workers == 2 })
+	tasks.Concurrent(6)
+	waitStats(t, tasks, func(s Stats) bool { return s.InWork == 6 && s.Workers == 6 })
+	// Лишние работники завершаются после выполнения текущей задачи, задачи не теряются
+	tasks.Concurrent(1)
+	waitStats(t, tasks, func(s Stats) bool { return s.Workers == 1 })
+	close(release)
+	tasks.Wait()
+	if done != 20 || tasks.GetTasksNumber() != 0 {
+		t.Fatalf("Unexpected number of done tasks: %d", done)
+	}
+	if stats := tasks.Stats(); stats.Workers != 0 {
+		t.Fatalf("Unexpected stats after execution: %+v", stats)
+	}
+}
+
+// TestAutoscale Количество работников растёт при длинной очереди и уменьшается при простое
+func TestAutoscale(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1).Autoscale(1, 8)
+	var mu sync.Mutex
+	var running, peak int
+	var idle = make(chan struct{})
+
+	tasks.Worker(func(in int) error {
+		if in < 0 {
+			<-idle
+			return nil
+		}
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(time.Millisecond * 50)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	for i := 0; i < 64; i++ {
+		tasks.AddTask(i)
+	}
+	// Задача удерживающая таскер запущенным после выполнения остальных задач
+	tasks.AddTask(-1)
+	tasks.Run()
+	waitStats(t, tasks, func(s Stats) bool { return s.Total == 1 })
+	if peak < 4 || peak > 8 {
+		t.Fatalf("Unexpected peak of concurrent tasks: %d", peak)
+	}
+	tasks.AddTask(-2)
+	waitStats(t, tasks, func(s Stats) bool { return s.Workers <= 2 })
+	close(idle)
+	tasks.Wait()
+}
@@ -0,0 +1,85 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"container/heap"
+	"time"
+)
+
+// schedule Задачи ожидающие времени запуска, упорядоченные по времени запуска (container/heap)
+// Содержит как отложенные задачи, так и задачи ожидающие задержки перед повтором
+type schedule[T any] struct {
+	Items []*task[T] // Задачи
+}
+
+// Len Реализация интерфейса heap.Interface
+func (s *schedule[T]) Len() int { return len(s.Items) }
+
+// Less Реализация интерфейса heap.Interface
+func (s *schedule[T]) Less(i, j int) bool {
+	if !s.Items[i].NotBefore.Equal(s.Items[j].NotBefore) {
+		return s.Items[i].NotBefore.Before(s.Items[j].NotBefore)
+	}
+	return s.Items[i].ID < s.Items[j].ID
+}
+
+// Swap Реализация интерфейса heap.Interface
+func (s *schedule[T]) Swap(i, j int) {
+	s.Items[i], s.Items[j] = s.Items[j], s.Items[i]
+	s.Items[i].TimerIndex, s.Items[j].TimerIndex = i, j
+}
+
+// Push Реализация интерфейса heap.Interface
+func (s *schedule[T]) Push(x interface{}) {
+	var item = x.(*task[T])
+
+	item.TimerIndex = len(s.Items)
+	s.Items = append(s.Items, item)
+}
+
+// Pop Реализация интерфейса heap.Interface
+func (s *schedule[T]) Pop() interface{} {
+	var n = len(s.Items) - 1
+	var item = s.Items[n]
+
+	s.Items[n] = nil
+	s.Items = s.Items[:n]
+	item.TimerIndex = -1
+	return item
+}
+
+// Add Добавление задачи в расписание
+func (s *schedule[T]) Add(item *task[T]) { heap.Push(s, item) }
+
+// Due Извлечение задачи время запуска которой наступило, nil если таких задач нет
+func (s *schedule[T]) Due(now time.Time) *task[T] {
+	if len(s.Items) == 0 || s.Items[0].NotBefore.After(now) {
+		return nil
+	}
+	return heap.Pop(s).(*task[T])
+}
+
+// Next Ближайшее время запуска, нулевое время если расписание пусто
+func (s *schedule[T]) Next() (ret time.Time) {
+	if len(s.Items) > 0 {
+		ret = s.Items[0].NotBefore
+	}
+	return
+}
+
+// Remove Удаление задачи из расписания, если она в расписании
+func (s *schedule[T]) Remove(item *task[T]) {
+	if item.TimerIndex < 0 || item.TimerIndex >= len(s.Items) || s.Items[item.TimerIndex] != item {
+		return
+	}
+	heap.Remove(s, item.TimerIndex)
+}
+
+// Reset Удаление всех задач из расписания
+func (s *schedule[T]) Reset() {
+	for i := range s.Items {
+		s.Items[i].TimerIndex = -1
+	}
+	s.Items = s.Items[:0]
+}
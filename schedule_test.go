@@ -0,0 +1,61 @@
+package tasker
+
+import (
+	"testing"
+	"time"
+)
+
+// TestScheduled Отложенные задачи запускаются не раньше указанного времени
+func TestScheduled(t *testing.T) {
+	var tasks Tasker
+	var begin = time.Now()
+	var started = make(map[string]time.Time)
+	var stats Stats
+
+	tasks = NewTasker().
+		Concurrent(2).
+		Worker(func(in interface{}) error {
+			started[in.(string)] = time.Now()
+			return nil
+		})
+	_ = tasks.AddTaskAfter("after", time.Second/5)
+	_ = tasks.AddTaskAt("at", begin.Add(time.Second/10))
+	_ = tasks.AddTask("now")
+	if stats = tasks.Stats(); stats.Total != 3 || stats.New != 3 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	tasks.Run().Wait()
+	if len(started) != 3 {
+		t.Fatalf("Not all tasks was executed: %v", started)
+	}
+	if started["now"].Sub(begin) >= time.Second/10 {
+		t.Fatalf("Ready task was delayed: %s", started["now"].Sub(begin))
+	}
+	if started["at"].Sub(begin) < time.Second/10 || started["after"].Sub(begin) < time.Second/5 {
+		t.Fatalf("Scheduled task started early: at=%s, after=%s", started["at"].Sub(begin), started["after"].Sub(begin))
+	}
+	if stats = tasks.Stats(); stats != (Stats{}) {
+		t.Fatalf("Unexpected stats after execution: %+v", stats)
+	}
+}
+
+// TestScheduleOrder Расписание упорядочено по времени запуска
+func TestScheduleOrder(t *testing.T) {
+	var s = new(schedule[interface{}])
+	var now = time.Now()
+	var late = &task[interface{}]{ID: 1, taskParams: taskParams{NotBefore: now.Add(time.Minute)}}
+	var early = &task[interface{}]{ID: 2, taskParams: taskParams{NotBefore: now.Add(time.Second)}}
+
+	s.Add(late)
+	s.Add(early)
+	if s.Next() != early.NotBefore || s.Due(now) != nil {
+		t.Fatalf("Unexpected schedule state")
+	}
+	if s.Due(now.Add(time.Second)) != early || s.Due(now.Add(time.Second)) != nil {
+		t.Fatalf("Early task not due")
+	}
+	s.Remove(late)
+	if s.Len() != 0 || !s.Next().IsZero() {
+		t.Fatalf("Task not removed from schedule")
+	}
+}
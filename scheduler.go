@@ -0,0 +1,396 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Scheduler is an interface of recurring task scheduler
+type Scheduler interface {
+	Add(spec string, fn JobFunc, overlap OverlapPolicy) (JobID, error)        // Добавление задания по cron выражению
+	AddSchedule(s Schedule, fn JobFunc, overlap OverlapPolicy) (JobID, error) // Добавление задания с произвольным расписанием
+	Clock(Clock) Scheduler                                                    // Установка источника времени, по умолчанию системное время
+	Entries() []Entry                                                         // Список заданий с временем следующего запуска
+	Error() error                                                             // Последняя возникшая ошибка добавления задачи в таскер
+	Location(*time.Location) Scheduler                                        // Часовой пояс расписаний, по умолчанию time.Local
+	NextRun(JobID) (time.Time, error)                                         // Время следующего запуска задания
+	Remove(JobID) error                                                       // Удаление задания
+	Start() Scheduler                                                         // Запуск планировщика
+	Stop() Scheduler                                                          // Остановка планировщика, функция блокируется до завершения процесса планировщика
+}
+
+// Clock Источник времени планировщика, позволяет подменить время в тестах
+type Clock interface {
+	Now() time.Time                         // Текущее время
+	After(d time.Duration) <-chan time.Time // Канал получающий значение по прошествии указанного времени
+}
+
+// JobFunc Функция создающая объект задачи для очередного запуска задания
+// Получает запланированное время запуска, если функция вернула nil, задача не добавляется
+type JobFunc func(time.Time) interface{}
+
+// JobID Идентификатор задания планировщика
+type JobID uint64
+
+// OverlapPolicy Поведение при наступлении времени запуска задания, если предыдущая задача задания ещё не завершена
+type OverlapPolicy int
+
+const (
+	// AllowOverlap Добавлять новую задачу независимо от предыдущей
+	AllowOverlap OverlapPolicy = iota
+
+	// SkipIfRunning Пропускать запуск, если предыдущая задача задания ещё не завершена
+	SkipIfRunning
+)
+
+// Entry Описание задания планировщика
+type Entry struct {
+	ID      JobID         // Идентификатор задания
+	Spec    string        // Cron выражение задания, пустое для заданий с произвольным расписанием
+	Overlap OverlapPolicy // Поведение при наложении запусков
+	Prev    time.Time     // Время предыдущего запуска
+	Next    time.Time     // Время следующего запуска, нулевое если запусков больше не будет
+	Runs    int           // Количество добавленных в таскер задач
+	Skipped int           // Количество пропущенных запусков
+}
+
+// scheduler is an scheduler implementation
+type scheduler struct {
+	Tasker   Tasker         // Таскер в который добавляются задачи
+	Jobs     []*job         // Задания
+	LastID   JobID          // Последний присвоенный заданию идентификатор
+	Loc      *time.Location // Часовой пояс расписаний
+	Clk      Clock          // Источник времени
+	Err      error          // Последняя ошибка
+	Wake     chan struct{}  // Сигнал изменения списка заданий
+	Shutdown chan struct{}  // Сигнал остановки планировщика
+	LoopWG   sync.WaitGroup // Ожидание завершения процесса планировщика
+
+	sync.Mutex
+}
+
+// job Задание планировщика
+type job struct {
+	Entry
+	Schedule Schedule // Расписание
+	Fn       JobFunc  // Функция создающая объект задачи
+	Last     Handle   // Дескриптор последней добавленной задачи
+}
+
+// realClock Системное время
+type realClock struct{}
+
+// Now Текущее время
+func (realClock) Now() time.Time { return time.Now() }
+
+// After Канал получающий значение по прошествии указанного времени
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// NewScheduler Function create new scheduler of recurring tasks
+// На каждый запуск задания в таскер добавляется новая задача, если таскер не запущен, он запускается
+func NewScheduler(t Tasker) Scheduler {
+	var s = new(scheduler)
+
+	s.Tasker = t
+	s.Loc = time.Local
+	s.Clk = realClock{}
+	s.Wake = make(chan struct{}, 1)
+	return s
+}
+
+// Add Добавление задания по cron выражению, см. ParseCron
+func (s *scheduler) Add(spec string, fn JobFunc, overlap OverlapPolicy) (ret JobID, err error) {
+	var sch Schedule
+
+	if sch, err = ParseCron(spec); err != nil {
+		return
+	}
+	if ret, err = s.AddSchedule(sch, fn, overlap); err != nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if j := s.Job(ret); j != nil {
+		j.Spec = spec
+	}
+	return
+}
+
+// AddSchedule Добавление задания с произвольным расписанием
+func (s *scheduler) AddSchedule(sch Schedule, fn JobFunc, overlap OverlapPolicy) (ret JobID, err error) {
+	var j *job
+
+	if sch == nil || fn == nil {
+		err = fmt.Errorf("Not specified schedule or job function")
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.LastID++
+	j = &job{Schedule: sch, Fn: fn}
+	j.ID, j.Overlap = s.LastID, overlap
+	j.Next = sch.Next(s.Clk.Now().In(s.Loc))
+	s.Jobs = append(s.Jobs, j)
+	s.Signal()
+	ret = j.ID
+	return
+}
+
+// Remove Удаление задания
+func (s *scheduler) Remove(id JobID) (err error) {
+	s.Lock()
+	defer s.Unlock()
+	for i := range s.Jobs {
+		if s.Jobs[i].ID != id {
+			continue
+		}
+		s.Jobs = append(s.Jobs[:i], s.Jobs[i+1:]...)
+		s.Signal()
+		return
+	}
+	err = fmt.Errorf("Job %d not found", id)
+	return
+}
+
+// Entries Список заданий с временем следующего запуска
+func (s *scheduler) Entries() (ret []Entry) {
+	s.Lock()
+	defer s.Unlock()
+	for i := range s.Jobs {
+		ret = append(ret, s.Jobs[i].Entry)
+	}
+	return
+}
+
+// NextRun Время следующего запуска задания
+func (s *scheduler) NextRun(id JobID) (ret time.Time, err error) {
+	var j *job
+
+	s.Lock()
+	defer s.Unlock()
+	if j = s.Job(id); j == nil {
+		err = fmt.Errorf("Job %d not found", id)
+		return
+	}
+	ret = j.Next
+	return
+}
+
+// Location Часовой пояс расписаний, по умолчанию time.Local
+// Часовой пояс указанный в cron выражении префиксом CRON_TZ= имеет приоритет
+func (s *scheduler) Location(loc *time.Location) Scheduler {
+	s.Lock()
+	defer s.Unlock()
+	if loc != nil {
+		s.Loc = loc
+		s.Reschedule()
+	}
+	return s
+}
+
+// Clock Установка источника времени, по умолчанию системное время
+func (s *scheduler) Clock(c Clock) Scheduler {
+	s.Lock()
+	defer s.Unlock()
+	if c != nil {
+		s.Clk = c
+		s.Reschedule()
+	}
+	return s
+}
+
+// Error Последняя возникшая ошибка добавления задачи в таскер
+func (s *scheduler) Error() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.Err
+}
+
+// Start Запуск планировщика
+func (s *scheduler) Start() Scheduler {
+	s.Lock()
+	defer s.Unlock()
+	if s.Shutdown != nil {
+		return s
+	}
+	// Изменения заданий до запуска учитываются при первом проходе процесса планировщика
+	select {
+	case <-s.Wake:
+	default:
+	}
+	s.Shutdown = make(chan struct{})
+	s.LoopWG.Add(1)
+	go func() {
+		defer s.LoopWG.Done()
+		s.Loop(s.Shutdown)
+	}()
+	return s
+}
+
+// Stop Остановка планировщика, функция блокируется до завершения процесса планировщика
+// Уже добавленные в таскер задачи не прерываются
+func (s *scheduler) Stop() Scheduler {
+	s.Lock()
+	if s.Shutdown != nil {
+		close(s.Shutdown)
+		s.Shutdown = nil
+	}
+	s.Unlock()
+	s.LoopWG.Wait()
+	return s
+}
+
+// Loop Процесс планировщика, ожидает ближайшее время запуска и добавляет задачи в таскер
+func (s *scheduler) Loop(shutdown chan struct{}) {
+	var now, next time.Time
+	var due []*job
+	var wakeup <-chan time.Time
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	// Остановка планировщика прерывает ожидание места в очереди таскера
+	defer cancel()
+	go func() {
+		select {
+		case <-shutdown:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for {
+		s.Lock()
+		now = s.Clk.Now()
+		due, next = s.Due(now)
+		if wakeup = nil; len(due) == 0 && !next.IsZero() {
+			wakeup = s.Clk.After(next.Sub(now))
+		}
+		s.Unlock()
+		if len(due) > 0 {
+			for i := range due {
+				s.Fire(ctx, due[i])
+			}
+			continue
+		}
+		select {
+		case <-shutdown:
+			return
+		case <-s.Wake:
+		case <-wakeup:
+		}
+	}
+}
+
+// Due Задания время запуска которых наступило и ближайшее время запуска остальных заданий
+// Время следующего запуска наступивших заданий пересчитывается
+func (s *scheduler) Due(now time.Time) (ret []*job, next time.Time) {
+	var j *job
+
+	for _, j = range s.Jobs {
+		if !j.Next.IsZero() && !j.Next.After(now) {
+			ret = append(ret, j)
+			j.Prev, j.Next = j.Next, j.Schedule.Next(now.In(s.Loc))
+		}
+		if !j.Next.IsZero() && (next.IsZero() || j.Next.Before(next)) {
+			next = j.Next
+		}
+	}
+	return
+}
+
+// Fire Добавление в таскер задачи задания
+// При заполненной очереди с политикой OverflowBlock задача добавляется после освобождения места,
+// ожидание прерывается остановкой планировщика
+func (s *scheduler) Fire(ctx context.Context, j *job) {
+	var body interface{}
+	var last, h Handle
+	var at time.Time
+	var err error
+
+	s.Lock()
+	last, at = j.Last, j.Prev
+	s.Unlock()
+	if j.Overlap == SkipIfRunning && last != nil && !isDone(last) {
+		s.Lock()
+		j.Skipped++
+		s.Unlock()
+		return
+	}
+	if body = s.SafeCallJobFunc(j.Fn, at); body == nil {
+		return
+	}
+	// Место в заполненной очереди не освободится, пока таскер не запущен
+	if !s.Tasker.IsWork() && s.Tasker.GetTasksNumber() > 0 {
+		err = s.Tasker.Run().Error()
+	}
+	if err == nil {
+		h, err = s.Tasker.SubmitCtx(ctx, body)
+	}
+	if err == nil && !s.Tasker.IsWork() {
+		err = s.Tasker.Run().Error()
+	}
+	s.Lock()
+	defer s.Unlock()
+	if h != nil {
+		j.Last = h
+		j.Runs++
+	}
+	// Прерванное остановкой планировщика ожидание места в очереди не является ошибкой
+	if err != nil && ctx.Err() == nil {
+		s.Err = err
+	}
+}
+
+// SafeCallJobFunc Безопасный запуск внешней функции создающей объект задачи
+func (s *scheduler) SafeCallJobFunc(fn JobFunc, at time.Time) (ret interface{}) {
+	defer func() {
+		if e := recover(); e != nil {
+			s.Lock()
+			s.Err = fmt.Errorf("Recovery panic call external JobFunc: %v", e)
+			s.Unlock()
+			ret = nil
+		}
+	}()
+	ret = fn(at)
+	return
+}
+
+// Job Поиск задания по идентификатору
+func (s *scheduler) Job(id JobID) *job {
+	for i := range s.Jobs {
+		if s.Jobs[i].ID == id {
+			return s.Jobs[i]
+		}
+	}
+	return nil
+}
+
+// Reschedule Пересчёт времени следующего запуска всех заданий
+func (s *scheduler) Reschedule() {
+	var now = s.Clk.Now().In(s.Loc)
+
+	for i := range s.Jobs {
+		s.Jobs[i].Next = s.Jobs[i].Schedule.Next(now)
+	}
+	s.Signal()
+}
+
+// Signal Сигнал процессу планировщика об изменении заданий
+func (s *scheduler) Signal() {
+	select {
+	case s.Wake <- struct{}{}:
+	default:
+	}
+}
+
+// isDone Задача дескриптора завершена
+func isDone(h Handle) bool {
+	select {
+	case <-h.Done():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,182 @@
+package tasker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock Управляемый источник времени для тестов
+type fakeClock struct {
+	Time    time.Time
+	Waiters []fakeWaiter
+	Added   chan struct{}
+
+	sync.Mutex
+}
+
+type fakeWaiter struct {
+	At time.Time
+	C  chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{Time: now, Added: make(chan struct{}, 100)}
+}
+
+func (fc *fakeClock) Now() time.Time {
+	fc.Lock()
+	defer fc.Unlock()
+	return fc.Time
+}
+
+func (fc *fakeClock) After(d time.Duration) <-chan time.Time {
+	var c = make(chan time.Time, 1)
+
+	fc.Lock()
+	defer fc.Unlock()
+	fc.Waiters = append(fc.Waiters, fakeWaiter{At: fc.Time.Add(d), C: c})
+	fc.Added <- struct{}{}
+	return c
+}
+
+// Advance Перевод времени вперёд с срабатыванием наступивших ожиданий
+func (fc *fakeClock) Advance(d time.Duration) {
+	var waiters []fakeWaiter
+
+	fc.Lock()
+	defer fc.Unlock()
+	fc.Time = fc.Time.Add(d)
+	for _, w := range fc.Waiters {
+		if w.At.After(fc.Time) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.C <- fc.Time
+	}
+	fc.Waiters = waiters
+}
+
+// WaitWaiter Ожидание пока планировщик начнёт ожидать время следующего запуска
+func (fc *fakeClock) WaitWaiter(t *testing.T) {
+	select {
+	case <-fc.Added:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("Scheduler is not waiting")
+	}
+}
+
+// TestScheduler Задачи добавляются в таскер по расписанию
+func TestScheduler(t *testing.T) {
+	var clock = newFakeClock(time.Date(2024, time.January, 1, 0, 0, 30, 0, time.UTC))
+	var done = make(chan interface{}, 10)
+	var sch Scheduler
+	var id JobID
+	var next time.Time
+	var err error
+
+	sch = NewScheduler(NewTasker().Concurrent(1).Worker(func(in interface{}) error {
+		done <- in
+		return nil
+	})).Clock(clock).Location(time.UTC)
+	if id, err = sch.Add("* * * * *", func(at time.Time) interface{} { return at }, AllowOverlap); err != nil {
+		t.Fatalf("Add job error: %v", err)
+	}
+	if next, _ = sch.NextRun(id); !next.Equal(time.Date(2024, time.January, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected next run: %s", next)
+	}
+	sch.Start()
+	defer sch.Stop()
+	for i := 1; i <= 3; i++ {
+		clock.WaitWaiter(t)
+		clock.Advance(time.Minute)
+		select {
+		case at := <-done:
+			if expected := time.Date(2024, time.January, 1, 0, i, 0, 0, time.UTC); !at.(time.Time).Equal(expected) {
+				t.Fatalf("Unexpected job time: %v", at)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Task was not executed")
+		}
+	}
+	if entries := sch.Entries(); len(entries) != 1 || entries[0].Runs != 3 || entries[0].Spec != "* * * * *" {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+	if err = sch.Remove(id); err != nil || len(sch.Entries()) != 0 {
+		t.Fatalf("Job not removed: %v", err)
+	}
+}
+
+// TestSchedulerSkipIfRunning Запуск пропускается, пока предыдущая задача задания не завершена
+func TestSchedulerSkipIfRunning(t *testing.T) {
+	var clock = newFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	var started = make(chan interface{}, 10)
+	var release = make(chan interface{})
+	var sch Scheduler
+	var entries []Entry
+
+	sch = NewScheduler(NewTasker().Concurrent(2).Worker(func(in interface{}) error {
+		started <- in
+		<-release
+		return nil
+	})).Clock(clock)
+	_, _ = sch.Add("@every 1m", func(at time.Time) interface{} { return at }, SkipIfRunning)
+	sch.Start()
+	clock.WaitWaiter(t)
+	clock.Advance(time.Minute)
+	<-started
+	for i := 0; i < 2; i++ {
+		clock.WaitWaiter(t)
+		clock.Advance(time.Minute)
+	}
+	clock.WaitWaiter(t)
+	sch.Stop()
+	close(release)
+	if entries = sch.Entries(); entries[0].Runs != 1 || entries[0].Skipped != 2 {
+		t.Fatalf("Unexpected entry: %+v", entries[0])
+	}
+}
+
+// TestSchedulerStopBlocked Остановка планировщика прерывает ожидание места в заполненной очереди таскера
+func TestSchedulerStopBlocked(t *testing.T) {
+	var clock = newFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	var started = make(chan interface{}, 10)
+	var release = make(chan interface{})
+	var stopped = make(chan interface{})
+	var tasks Tasker
+	var sch Scheduler
+
+	defer close(release)
+	tasks = NewTasker().Concurrent(1).MaxQueued(1, OverflowBlock).Worker(func(in interface{}) error {
+		started <- in
+		<-release
+		return nil
+	})
+	sch = NewScheduler(tasks).Clock(clock)
+	_, _ = sch.Add("@every 1m", func(at time.Time) interface{} { return at }, AllowOverlap)
+	sch.Start()
+	clock.WaitWaiter(t)
+	clock.Advance(time.Minute)
+	<-started
+	for i := 0; i < 2; i++ {
+		clock.WaitWaiter(t)
+		clock.Advance(time.Minute)
+	}
+	// Третья задача ожидает места в очереди, пока выполняется первая и ожидает запуска вторая
+	time.Sleep(time.Second / 20)
+	go func() {
+		sch.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("Scheduler blocked by full queue")
+	}
+	if entries := sch.Entries(); entries[0].Runs != 2 || sch.Error() != nil {
+		t.Fatalf("Unexpected entry: %+v, %v", entries[0], sch.Error())
+	}
+	if n := tasks.GetTasksNumber(); n != 2 {
+		t.Fatalf("Unexpected number of tasks: %d", n)
+	}
+}
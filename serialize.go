@@ -0,0 +1,108 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"container/list"
+)
+
+// SerializeBy Последовательное выполнение задач с одинаковым ключом, ключ задачи определяется функцией key
+// Задачи одного ключа выполняются строго по одной в порядке добавления, задачи разных ключей выполняются параллельно
+// Следующая задача ключа запускается только после завершения предыдущей, в том числе после всех её повторов
+// Ключ вычисляется при добавлении задачи, значение key = nil отключает последовательное выполнение
+func (tsk *implementation[T]) SerializeBy(key func(T) string) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	if tsk.SerialKey = key; key == nil {
+		tsk.Unserialize()
+	}
+	tsk.Signal()
+	return tsk
+}
+
+// Serialize Помещение задачи в конец очереди задач её ключа, вызывается под блокировкой таскера
+func (tsk *implementation[T]) Serialize(item *task[T]) {
+	var line *list.List
+	var ok bool
+
+	if tsk.SerialKey == nil {
+		return
+	}
+	if tsk.Lines == nil {
+		tsk.Lines = make(map[string]*list.List)
+	}
+	item.Line = tsk.SafeSerialKey(item.Body)
+	if line, ok = tsk.Lines[item.Line]; !ok {
+		line = list.New()
+		tsk.Lines[item.Line] = line
+	}
+	item.LineElement = line.PushBack(item)
+}
+
+// SafeSerialKey Безопасный вызов внешней функции получения ключа, при панике используется пустой ключ
+func (tsk *implementation[T]) SafeSerialKey(body T) (ret string) {
+	defer func() {
+		if e := recover(); e != nil {
+			ret = ""
+		}
+	}()
+	ret = tsk.SerialKey(body)
+	return
+}
+
+// Behind Задача ожидает завершения предыдущей задачи своего ключа
+func (t *task[T]) Behind() bool {
+	return t.LineElement != nil && t.LineElement.Prev() != nil
+}
+
+// Advance Удаление завершённой задачи из очереди задач её ключа и постановка в очередь следующей задачи ключа
+func (tsk *implementation[T]) Advance(item *task[T]) {
+	var line *list.List
+	var next *list.Element
+	var ok bool
+
+	if item.LineElement == nil {
+		return
+	}
+	if line, ok = tsk.Lines[item.Line]; !ok {
+		item.LineElement = nil
+		return
+	}
+	next = item.LineElement.Next()
+	line.Remove(item.LineElement)
+	item.LineElement = nil
+	if line.Len() == 0 {
+		delete(tsk.Lines, item.Line)
+	}
+	if next != nil {
+		tsk.Unblock(next.Value.(*task[T]))
+	}
+}
+
+// Unblock Постановка в очередь задачи, дождавшейся завершения предыдущей задачи своего ключа
+// Задачи ещё не обработанные BootstrapFunc попадут в очередь после обработки
+func (tsk *implementation[T]) Unblock(item *task[T]) {
+	if tsk.Pending[item.ID] != item || !item.Prelude || item.InWork {
+		return
+	}
+	tsk.Enqueue(item)
+}
+
+// Unserialize Отключение последовательного выполнения, ожидающие задачи ставятся в очередь
+func (tsk *implementation[T]) Unserialize() {
+	var line *list.List
+	var elm *list.Element
+	var item *task[T]
+	var behind bool
+
+	for _, line = range tsk.Lines {
+		for elm = line.Front(); elm != nil; elm = elm.Next() {
+			item, behind = elm.Value.(*task[T]), elm.Prev() != nil
+			item.LineElement = nil
+			if behind {
+				tsk.Unblock(item)
+			}
+		}
+	}
+	tsk.Lines = nil
+}
@@ -0,0 +1,94 @@
+package tasker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSerializeBy Задачи одного ключа выполняются по одной в порядке добавления, повторы блокируют следующие задачи ключа
+func TestSerializeBy(t *testing.T) {
+	var tasks = NewTyped[string]().Concurrent(4).RetryIfError(3)
+	var mu sync.Mutex
+	var running = make(map[string]int)
+	var order = make(map[string][]string)
+	var failed = make(map[string]bool)
+	var parallel bool
+
+	tasks.SerializeBy(func(in string) string { return strings.Split(in, "/")[0] })
+	tasks.Worker(func(in string) error {
+		var key = strings.Split(in, "/")[0]
+
+		mu.Lock()
+		if running[key]++; running[key] > 1 {
+			parallel = true
+		}
+		order[key] = append(order[key], in)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		running[key]--
+		// Вторая задача каждого ключа первый раз завершается ошибкой
+		if strings.HasSuffix(in, "/1") && !failed[in] {
+			failed[in] = true
+			return fmt.Errorf("Temporary error")
+		}
+		return nil
+	})
+	for i := 0; i < 4; i++ {
+		for _, key := range []string{"a", "b", "c"} {
+			tasks.AddTask(fmt.Sprintf("%s/%d", key, i))
+		}
+	}
+	if stats := tasks.Stats(); stats.Total != 12 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	tasks.Run().Wait()
+	if parallel {
+		t.Fatalf("Tasks of the same key executed in parallel")
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		var expected = fmt.Sprintf("[%[1]s/0 %[1]s/1 %[1]s/1 %[1]s/2 %[1]s/3]", key)
+		if fmt.Sprint(order[key]) != expected {
+			t.Fatalf("Unexpected order of key %s: %v", key, order[key])
+		}
+	}
+}
+
+// TestSerializeByStats Задачи ожидающие предыдущую задачу ключа учитываются как заблокированные
+func TestSerializeByStats(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(4).SerializeBy(func(in int) string { return fmt.Sprint(in % 2) })
+	var release = make(chan struct{})
+	var stats Stats
+
+	tasks.Worker(func(int) error { <-release; return nil })
+	for i := 0; i < 6; i++ {
+		tasks.AddTask(i)
+	}
+	tasks.Run()
+	for deadline := time.Now().Add(time.Second); tasks.Stats().InWork != 2; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Tasks not started: %+v", tasks.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if stats = tasks.Stats(); stats.Blocked != 4 || stats.Ready != 0 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	// После отключения последовательного выполнения ожидающие задачи выполняются параллельно
+	tasks.SerializeBy(nil)
+	for deadline := time.Now().Add(time.Second); tasks.Stats().InWork != 4; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Tasks not started: %+v", tasks.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	tasks.Wait()
+	if stats = tasks.Stats(); stats.Total != 0 {
+		t.Fatalf("Unexpected stats after execution: %+v", stats)
+	}
+}
@@ -0,0 +1,102 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"context"
+	"time"
+)
+
+// ShutdownMode Режим остановки таскера
+type ShutdownMode int
+
+const (
+	// ShutdownDrain Выполнить все задачи очереди, включая задачи добавленные во время остановки и задачи читаемые из источников
+	// Остановка не завершается, пока таскер приостановлен, пока не исчерпаны источники задач или пока задачи продолжают
+	// добавляться, поэтому её ограничивают контекстом остановки
+	ShutdownDrain ShutdownMode = iota
+
+	// ShutdownFinish Прекратить запуск задач и дождаться окончания выполняющихся задач, контексты задач не отменяются
+	ShutdownFinish
+
+	// ShutdownAbort Прекратить запуск задач и отменить контексты выполняющихся задач
+	ShutdownAbort
+)
+
+// ShutdownReport Отчёт об остановке таскера
+type ShutdownReport struct {
+	Mode             ShutdownMode  // Режим остановки
+	Completed        []TaskID      // Задачи успешно выполненные во время остановки
+	Failed           []TaskID      // Задачи завершившиеся ошибкой во время остановки, в том числе удалённые и пропущенные
+	Abandoned        []TaskID      // Задачи оставшиеся в очереди, они будут выполнены при следующем запуске
+	DeadlineExceeded bool          // Контекст остановки завершился раньше окончания остановки
+	Elapsed          time.Duration // Продолжительность остановки
+}
+
+// Shutdown Остановка таскера в указанном режиме с ожиданием окончания остановки
+// Если контекст завершится раньше окончания остановки, контексты выполняющихся задач отменяются, запуск задач прекращается
+// и функция возвращает отчёт не дожидаясь работников, которые не реагируют на отмену контекста задачи
+// Для не запущенного таскера все задачи очереди возвращаются в отчёте как оставленные
+func (tsk *implementation[T]) Shutdown(ctx context.Context, mode ShutdownMode) (ret ShutdownReport) {
+	var started = time.Now()
+	var cancel context.CancelFunc
+	var done = make(chan struct{})
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	tsk.Lock()
+	ret.Mode = mode
+	if !tsk.isWork {
+		ret.Abandoned = tsk.Abandoned()
+		tsk.Unlock()
+		return
+	}
+	tsk.Report, cancel = &ret, tsk.Cancel
+	tsk.Lenient = mode == ShutdownFinish
+	tsk.Unlock()
+	switch mode {
+	case ShutdownFinish:
+		tsk.Interrupt()
+	case ShutdownAbort:
+		cancel()
+		tsk.Interrupt()
+	}
+	go func() {
+		tsk.WorkerWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		ret.DeadlineExceeded = true
+		cancel()
+		tsk.Interrupt()
+	}
+	tsk.Lock()
+	tsk.Report = nil
+	ret.Abandoned, ret.Elapsed = tsk.Abandoned(), time.Since(started)
+	tsk.Unlock()
+
+	return
+}
+
+// Abandoned Идентификаторы не завершённых задач
+func (tsk *implementation[T]) Abandoned() (ret []TaskID) {
+	ret = make([]TaskID, 0, tsk.Tasks.Len())
+	for elm := tsk.Tasks.Front(); elm != nil; elm = elm.Next() {
+		ret = append(ret, elm.Value.(*task[T]).ID)
+	}
+	return
+}
+
+// Record Учёт завершённой задачи в отчёте об остановке, вызывается под блокировкой таскера
+func (tsk *implementation[T]) Record(rsl TypedResult[T]) {
+	switch {
+	case tsk.Report == nil:
+	case rsl.Error == nil:
+		tsk.Report.Completed = append(tsk.Report.Completed, rsl.ID)
+	default:
+		tsk.Report.Failed = append(tsk.Report.Failed, rsl.ID)
+	}
+}
@@ -0,0 +1,243 @@
+package tasker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestShutdownDrain Остановка с выполнением всех задач очереди
+func TestShutdownDrain(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(2)
+	var rpt ShutdownReport
+
+	tasks.Worker(func(int) error { time.Sleep(time.Millisecond); return nil })
+	for i := 0; i < 10; i++ {
+		tasks.AddTask(i)
+	}
+	rpt = tasks.Run().Shutdown(context.Background(), ShutdownDrain)
+	if len(rpt.Completed) != 10 || len(rpt.Failed) != 0 || len(rpt.Abandoned) != 0 || rpt.DeadlineExceeded {
+		t.Fatalf("Unexpected report: %+v", rpt)
+	}
+	if tasks.IsWork() || tasks.GetTasksNumber() != 0 {
+		t.Fatalf("Tasker not stopped")
+	}
+}
+
+// TestShutdownFinish Остановка с окончанием выполняющихся задач без отмены их контекстов
+func TestShutdownFinish(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(2)
+	var started = make(chan struct{}, 2)
+	var mu sync.Mutex
+	var cancelled int
+	var parent = &trackContext{Context: context.Background(), Wait: make(chan struct{})}
+	var rpt ShutdownReport
+
+	tasks.WorkerCtx(func(ctx context.Context, _ int) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		time.Sleep(time.Millisecond * 20)
+		mu.Lock()
+		if ctx.Err() != nil {
+			cancelled++
+		}
+		mu.Unlock()
+		return nil
+	})
+	for i := 0; i < 10; i++ {
+		tasks.AddTask(i)
+	}
+	tasks.RunContext(parent)
+	<-started
+	<-started
+	rpt = tasks.Shutdown(context.Background(), ShutdownFinish)
+	if len(rpt.Completed) != 2 || len(rpt.Abandoned) != 8 || rpt.DeadlineExceeded || cancelled != 0 {
+		t.Fatalf("Unexpected report: %+v, cancelled %d", rpt, cancelled)
+	}
+	if tasks.GetTasksNumber() != 8 {
+		t.Fatalf("Abandoned tasks not kept in queue: %d", tasks.GetTasksNumber())
+	}
+	// Контекст запуска освобождается и не остаётся зарегистрированным в родительском контексте
+	tasks.Wait()
+	if n := parent.Children(); n != 0 {
+		t.Fatalf("Run context not released: %d", n)
+	}
+	if rpt = tasks.Run().Shutdown(context.Background(), ShutdownDrain); len(rpt.Completed) != 8 {
+		t.Fatalf("Abandoned tasks not executed on next run: %+v", rpt)
+	}
+}
+
+// TestShutdownAbort Остановка с отменой контекстов выполняющихся задач
+func TestShutdownAbort(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1).RetryIfError(3)
+	var started = make(chan struct{})
+	var h Handle
+	var rpt ShutdownReport
+
+	tasks.WorkerCtx(func(ctx context.Context, _ int) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	h, _ = tasks.Submit(1)
+	tasks.AddTask(2)
+	tasks.Run()
+	<-started
+	rpt = tasks.Shutdown(context.Background(), ShutdownAbort)
+	// Прерванная задача не считается завершившейся ошибкой и остаётся в очереди
+	if len(rpt.Completed) != 0 || len(rpt.Failed) != 0 || len(rpt.Abandoned) != 2 || rpt.DeadlineExceeded {
+		t.Fatalf("Unexpected report: %+v", rpt)
+	}
+	if tasks.Stats().Dead != 0 || h.Status() != StatusQueued {
+		t.Fatalf("Aborted task moved to dead tasks or retried: %+v, %v", tasks.Stats(), h.Status())
+	}
+}
+
+// TestShutdownDeadline Истечение времени остановки отменяет контексты выполняющихся задач
+func TestShutdownDeadline(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1)
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*20)
+	var rpt ShutdownReport
+
+	defer cancel()
+	tasks.WorkerCtx(func(ctx context.Context, _ int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	for i := 0; i < 3; i++ {
+		tasks.AddTask(i)
+	}
+	rpt = tasks.Run().Shutdown(ctx, ShutdownDrain)
+	if !rpt.DeadlineExceeded || len(rpt.Completed) != 0 {
+		t.Fatalf("Unexpected report: %+v", rpt)
+	}
+	tasks.Wait()
+	if tasks.IsWork() || tasks.GetTasksNumber() != 3 {
+		t.Fatalf("Unexpected number of tasks after deadline: %d", tasks.GetTasksNumber())
+	}
+	if rpt = tasks.Shutdown(context.Background(), ShutdownAbort); len(rpt.Abandoned) != 3 {
+		t.Fatalf("Unexpected report of stopped tasker: %+v", rpt)
+	}
+}
+
+// trackContext Контекст считающий зарегистрированные в нём дочерние контексты
+type trackContext struct {
+	context.Context
+	Active int
+	Wait   chan struct{}
+	sync.Mutex
+}
+
+// Done Не закрываемый канал, без него дочерние контексты не регистрируются
+func (c *trackContext) Done() <-chan struct{} { return c.Wait }
+
+// AfterFunc Регистрация дочернего контекста, вызывается context.WithCancel
+func (c *trackContext) AfterFunc(func()) func() bool {
+	c.Lock()
+	defer c.Unlock()
+	c.Active++
+	return func() bool {
+		c.Lock()
+		defer c.Unlock()
+		c.Active--
+		return true
+	}
+}
+
+// Children Количество зарегистрированных дочерних контекстов
+func (c *trackContext) Children() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.Active
+}
+
+// TestShutdownAbortRestart Прерывание остановленного запуска не прерывает следующий запуск
+func TestShutdownAbortRestart(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(2)
+	var mu sync.Mutex
+	var done int
+
+	tasks.WorkerCtx(func(ctx context.Context, in int) error {
+		if in < 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		mu.Lock()
+		done++
+		mu.Unlock()
+		return nil
+	})
+	for n := 0; n < 20; n++ {
+		tasks.AddTask(-1)
+		tasks.Run()
+		tasks.Shutdown(context.Background(), ShutdownAbort)
+		tasks.Clean()
+		mu.Lock()
+		done = 0
+		mu.Unlock()
+		for i := 0; i < 5; i++ {
+			tasks.AddTask(i)
+		}
+		tasks.Run().Wait()
+		mu.Lock()
+		if done != 5 {
+			mu.Unlock()
+			t.Fatalf("Tasks of next run not executed: %d", done)
+		}
+		mu.Unlock()
+	}
+}
+
+// TestShutdownDrainPending Остановка ShutdownDrain ограничивается контекстом, пока очередь не может опустеть
+func TestShutdownDrainPending(t *testing.T) {
+	var cases = map[string]func(Typed[int]) func(){
+		"paused": func(tasks Typed[int]) func() {
+			tasks.AddTask(1)
+			tasks.Pause().Run()
+			return func() {}
+		},
+		"source": func(tasks Typed[int]) func() {
+			var ch = make(chan int)
+			tasks.RunFrom(context.Background(), FromChan(ch))
+			return func() { close(ch) }
+		},
+		"producer": func(tasks Typed[int]) func() {
+			var stop = make(chan struct{})
+			var done = make(chan struct{})
+			tasks.AddTask(1)
+			tasks.Run()
+			go func() {
+				defer close(done)
+				for {
+					select {
+					case <-stop:
+						return
+					case <-time.After(time.Millisecond):
+						tasks.AddTask(1)
+					}
+				}
+			}()
+			return func() { close(stop); <-done }
+		},
+	}
+
+	for name, setup := range cases {
+		var tasks = NewTyped[int]().Concurrent(1).Worker(func(int) error { time.Sleep(time.Millisecond * 5); return nil })
+		var ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*30)
+		var release = setup(tasks)
+		var rpt = tasks.Shutdown(ctx, ShutdownDrain)
+
+		cancel()
+		release()
+		if !rpt.DeadlineExceeded {
+			t.Fatalf("Drain of %s tasker not limited by context: %+v", name, rpt)
+		}
+		tasks.Wait()
+		if tasks.IsWork() {
+			t.Fatalf("Tasker %s not stopped", name)
+		}
+	}
+}
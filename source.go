@@ -0,0 +1,165 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"context"
+	"fmt"
+)
+
+// Source is an interface of lazy task source
+type Source[T any] interface {
+	// Next Получение следующего объекта задачи
+	// =false - источник исчерпан, ошибка прекращает чтение источника и прерывает выполнение задач
+	Next(ctx context.Context) (T, bool, error)
+}
+
+// SourceFunc Функция получения следующего объекта задачи, реализует интерфейс Source
+type SourceFunc[T any] func(ctx context.Context) (T, bool, error)
+
+// Next Получение следующего объекта задачи
+func (fn SourceFunc[T]) Next(ctx context.Context) (T, bool, error) { return fn(ctx) }
+
+// FromChan Источник задач читающий объекты задач из канала, источник исчерпан после закрытия канала
+func FromChan[T any](ch <-chan T) Source[T] {
+	return SourceFunc[T](func(ctx context.Context) (item T, ok bool, err error) {
+		select {
+		case item, ok = <-ch:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		return
+	})
+}
+
+// RunFrom Запуск выполнения задач читаемых из источника
+// Задачи читаются пакетами размера BatchSize(), по умолчанию равного Concurrent(), следующий пакет читается
+// только когда в очереди остаётся не больше Concurrent() не завершенных задач, поэтому источник не читается впрок
+// Если таскер уже запущен, источник подключается к текущему запуску, отмена контекста прекращает чтение источника
+// Таскер завершает работу после того как все источники исчерпаны и все задачи выполнены
+func (tsk *implementation[T]) RunFrom(ctx context.Context, src Source[T]) Typed[T] {
+	var size int
+	var feed context.Context
+	var cancel context.CancelFunc
+
+	tsk.Lock()
+	defer tsk.Unlock()
+	if src == nil {
+		tsk.Err = fmt.Errorf("Error, source is nil")
+		return tsk
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !tsk.isWork && !tsk.Start(ctx) {
+		return tsk
+	}
+	if size = tsk.Batch; size <= 0 {
+		size = tsk.ConcurrentProcesses
+	}
+	feed, cancel = context.WithCancel(tsk.Ctx)
+	tsk.Sources++
+	tsk.WorkerWG.Add(1)
+	go func() {
+		var stop = context.AfterFunc(ctx, cancel)
+
+		defer tsk.WorkerWG.Done()
+		defer cancel()
+		defer stop()
+		tsk.Feed(feed, src, size)
+	}()
+
+	return tsk
+}
+
+// Feed Чтение задач из источника пакетами указанного размера с ожиданием свободного места в очереди
+// Место в очереди ожидается до чтения каждого объекта задачи, поэтому прерывание ожидания не теряет прочитанные объекты
+func (tsk *implementation[T]) Feed(ctx context.Context, src Source[T], size int) {
+	var item T
+	var ok = true
+	var err error
+	var n int
+
+	for ok && err == nil && tsk.WaitRoom(ctx) {
+		for n = 0; ok && err == nil && n < size; n++ {
+			if err = tsk.Reserve(ctx); err != nil {
+				break
+			}
+			if item, ok, err = tsk.SafeCallSource(ctx, src); ok && err == nil {
+				err = tsk.Supply(ctx, item)
+			}
+		}
+	}
+	tsk.Lock()
+	// Ошибка чтения из-за прерывания выполнения задач не является ошибкой источника
+	if tsk.Sources--; err != nil && ctx.Err() == nil {
+		tsk.Err = err
+	}
+	tsk.Unlock()
+	tsk.Signal()
+}
+
+// Reserve Ожидание места в очереди для следующего объекта задачи источника
+func (tsk *implementation[T]) Reserve(ctx context.Context) error {
+	tsk.Lock()
+	defer tsk.Unlock()
+	return tsk.Admit(ctx)
+}
+
+// Supply Добавление в очередь прочитанного из источника объекта задачи
+// Если место в очереди заняли другие производители и ожидание места прервано, объект задачи не добавлен,
+// это сохраняется в Err, так как источник уже не вернёт этот объект повторно
+func (tsk *implementation[T]) Supply(ctx context.Context, item T) (err error) {
+	tsk.Lock()
+	defer tsk.Unlock()
+	if err = tsk.Admit(ctx); err != nil {
+		if tsk.Err == nil {
+			tsk.Err = fmt.Errorf("Task read from source is not added: %w", err)
+		}
+		return
+	}
+	_, err = tsk.Add(item)
+	return
+}
+
+// WaitRoom Ожидание пока в очереди останется не больше Concurrent() не завершенных задач
+// =false - ожидание прервано отменой контекста
+func (tsk *implementation[T]) WaitRoom(ctx context.Context) bool {
+	var room chan struct{}
+
+	for {
+		tsk.Lock()
+		if tsk.Tasks.Len() <= tsk.ConcurrentProcesses && ctx.Err() == nil {
+			tsk.Unlock()
+			return true
+		}
+		room = tsk.Room
+		tsk.Unlock()
+		select {
+		case <-room:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// Vacate Сигнал источникам задач и ожидающим места в очереди об освобождении места, вызывается под блокировкой таскера
+func (tsk *implementation[T]) Vacate() {
+	if tsk.Sources == 0 && tsk.Producers == 0 {
+		return
+	}
+	close(tsk.Room)
+	tsk.Room = make(chan struct{})
+}
+
+// SafeCallSource Безопасный вызов внешнего источника задач
+func (tsk *implementation[T]) SafeCallSource(ctx context.Context, src Source[T]) (item T, ok bool, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("Recovery panic call external Source: %v", e)
+			return
+		}
+	}()
+	item, ok, err = src.Next(ctx)
+	return
+}
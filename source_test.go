@@ -0,0 +1,139 @@
+package tasker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRunFrom Задачи читаются из источника пакетами, не опережая выполнение
+func TestRunFrom(t *testing.T) {
+	var tasks = NewTyped[int]()
+	var mu sync.Mutex
+	var read, done, maxAhead int
+	var batches []int
+	var src Source[int]
+
+	src = SourceFunc[int](func(ctx context.Context) (int, bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if read == 1000 {
+			return 0, false, nil
+		}
+		if read++; read-done > maxAhead {
+			maxAhead = read - done
+		}
+		return read, true, nil
+	})
+	tasks.Concurrent(4).
+		BatchSize(8).
+		Bootstrap(func(in []int) error {
+			batches = append(batches, len(in))
+			return nil
+		}).
+		Worker(func(in int) error {
+			time.Sleep(time.Microsecond * 50)
+			mu.Lock()
+			done++
+			mu.Unlock()
+			return nil
+		})
+	tasks.RunFrom(context.Background(), src).Wait()
+	if err := tasks.Error(); err != nil {
+		t.Fatalf("RunFrom error: %v", err)
+	}
+	if done != 1000 || tasks.GetTasksNumber() != 0 {
+		t.Fatalf("Unexpected number of done tasks: %d", done)
+	}
+	if maxAhead > 4+8+8 {
+		t.Fatalf("Source read too far ahead: %d", maxAhead)
+	}
+	for i := range batches {
+		if batches[i] > 8 {
+			t.Fatalf("Unexpected bootstrap batch size: %v", batches)
+		}
+	}
+}
+
+// TestRunFromChan Источник из канала и ошибка источника
+func TestRunFromChan(t *testing.T) {
+	var tasks = NewTasker()
+	var ch = make(chan interface{})
+	var sum int
+
+	tasks.Concurrent(1).Worker(func(in interface{}) error { sum += in.(int); return nil })
+	go func() {
+		for i := 1; i <= 100; i++ {
+			ch <- i
+		}
+		close(ch)
+	}()
+	tasks.RunFrom(context.Background(), FromChan(ch)).Wait()
+	if sum != 5050 || tasks.Error() != nil {
+		t.Fatalf("Unexpected sum: %d, error: %v", sum, tasks.Error())
+	}
+
+	tasks.RunFrom(context.Background(), SourceFunc[interface{}](func(ctx context.Context) (interface{}, bool, error) {
+		return nil, false, fmt.Errorf("Source failed")
+	})).Wait()
+	if err := tasks.Error(); err == nil || err.Error() != "Source failed" {
+		t.Fatalf("Unexpected source error: %v", err)
+	}
+}
+
+// TestRunFromCancel Отмена контекста прекращает чтение источника
+func TestRunFromCancel(t *testing.T) {
+	var tasks = NewTyped[int]().Worker(func(int) error { return nil })
+	var ctx, cancel = context.WithCancel(context.Background())
+	var ch = make(chan int)
+	var finished = make(chan struct{})
+
+	go func() {
+		tasks.RunFrom(ctx, FromChan(ch)).Wait()
+		close(finished)
+	}()
+	ch <- 1
+	cancel()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatalf("Tasker not stopped after cancel")
+	}
+	if tasks.IsWork() {
+		t.Fatalf("Tasker still working")
+	}
+}
+
+// TestRunFromBlockedCancel Прерывание ожидания места в очереди не теряет прочитанные из источника задачи
+func TestRunFromBlockedCancel(t *testing.T) {
+	var tasks = NewTyped[int]().Concurrent(1).BatchSize(4).MaxQueued(1, OverflowBlock)
+	var ctx, cancel = context.WithCancel(context.Background())
+	var release = make(chan struct{})
+	var mu sync.Mutex
+	var pulled int
+
+	defer cancel()
+	tasks.Worker(func(int) error { <-release; return nil })
+	tasks.RunFrom(ctx, SourceFunc[int](func(context.Context) (int, bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		pulled++
+		return pulled, true, nil
+	}))
+	time.Sleep(time.Millisecond * 50)
+	cancel()
+	time.Sleep(time.Millisecond * 20)
+	mu.Lock()
+	if total := tasks.Stats().Total; pulled != total {
+		mu.Unlock()
+		t.Fatalf("Read tasks lost: read %d, in queue %d", pulled, total)
+	}
+	mu.Unlock()
+	close(release)
+	tasks.Wait()
+	if err := tasks.Error(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
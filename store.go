@@ -0,0 +1,102 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store Хранилище не завершённых задач таскера
+// По умолчанию таскер использует хранилище в памяти процесса NewMemoryStore(), объекты задач в нём не сериализуются.
+// Таскер сохраняет каждое изменение состояния задачи в хранилище до продолжения работы с задачей,
+// поэтому после аварийного завершения процесса задачи постоянного хранилища восстанавливаются функцией NewTaskerFrom()
+type Store interface {
+	Enqueue(StoredTask) error    // Добавление или замена записи задачи, задача ожидает запуска
+	Lease(TaskID) error          // Задача передана работнику, до подтверждения задача считается выполняющейся
+	Ack(TaskID) error            // Задача завершена, запись задачи удаляется, неизвестный идентификатор не является ошибкой
+	Nack(StoredTask) error       // Задача возвращена в очередь после неудачной попытки выполнения или не была начата
+	List() ([]StoredTask, error) // Все записи задач в порядке возрастания идентификаторов
+	Close() error                // Освобождение ресурсов хранилища
+}
+
+// StoredTask Запись задачи в хранилище
+type StoredTask struct {
+	ID         TaskID        // Идентификатор задачи
+	Body       []byte        // Объект задачи сериализованный Codec
+	Priority   int           // Приоритет задачи
+	Timeout    time.Duration // Максимальное время выполнения задачи
+	Created    time.Time     // Время добавления задачи в очередь
+	NotBefore  time.Time     // Задача не может быть запущена раньше этого времени
+	Deps       []TaskID      // Задачи, после успешного выполнения которых может быть запущена задача
+	Key        string        // Ключ идемпотентности задачи
+	Prelude    bool          // =true - задача была обработана BootstrapFunc
+	InWork     bool          // =true - задача передана работнику и не подтверждена
+	Attempts   int           // Количество попыток выполнить задачу
+	CountError int           // Количество попыток выполнить задачу завершившихся ошибкой
+	Error      string        // Ошибка последней неудачной попытки выполнения
+	Updated    time.Time     // Время последнего изменения записи
+}
+
+// memoryStore Хранилище задач в памяти процесса, задачи не переживают завершение процесса
+type memoryStore struct {
+	Tasks map[TaskID]StoredTask // Записи задач
+	sync.Mutex
+}
+
+// NewMemoryStore Создание хранилища задач в памяти процесса
+func NewMemoryStore() Store {
+	return &memoryStore{Tasks: make(map[TaskID]StoredTask)}
+}
+
+// Enqueue Добавление или замена записи задачи
+func (ms *memoryStore) Enqueue(st StoredTask) error {
+	ms.Lock()
+	defer ms.Unlock()
+	st.InWork, st.Body = false, append([]byte(nil), st.Body...)
+	ms.Tasks[st.ID] = st
+	return nil
+}
+
+// Lease Отметка о передаче задачи работнику
+func (ms *memoryStore) Lease(id TaskID) error {
+	ms.Lock()
+	defer ms.Unlock()
+	if st, ok := ms.Tasks[id]; ok {
+		st.InWork, st.Prelude, st.Updated = true, true, time.Now()
+		ms.Tasks[id] = st
+	}
+	return nil
+}
+
+// Ack Удаление записи завершённой задачи
+func (ms *memoryStore) Ack(id TaskID) error {
+	ms.Lock()
+	defer ms.Unlock()
+	delete(ms.Tasks, id)
+	return nil
+}
+
+// Nack Возврат задачи в очередь
+func (ms *memoryStore) Nack(st StoredTask) error { return ms.Enqueue(st) }
+
+// List Все записи задач в порядке возрастания идентификаторов
+func (ms *memoryStore) List() (ret []StoredTask, err error) {
+	ms.Lock()
+	defer ms.Unlock()
+	ret = make([]StoredTask, 0, len(ms.Tasks))
+	for _, st := range ms.Tasks {
+		ret = append(ret, st)
+	}
+	sortStored(ret)
+	return
+}
+
+// Close Хранилище в памяти не требует освобождения ресурсов
+func (ms *memoryStore) Close() error { return nil }
+
+// sortStored Сортировка записей задач по возрастанию идентификаторов
+func sortStored(items []StoredTask) {
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+}
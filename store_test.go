@@ -0,0 +1,295 @@
+package tasker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testStore Проверка семантики хранилища задач
+func testStore(t *testing.T, store Store) {
+	var items []StoredTask
+	var err error
+
+	for i := 1; i <= 3; i++ {
+		if err = store.Enqueue(StoredTask{ID: TaskID(i), Body: []byte{byte(i)}}); err != nil {
+			t.Fatalf("Enqueue error: %v", err)
+		}
+	}
+	if err = store.Lease(2); err != nil {
+		t.Fatalf("Lease error: %v", err)
+	}
+	if err = store.Ack(1); err != nil || store.Ack(100) != nil {
+		t.Fatalf("Ack error: %v", err)
+	}
+	if err = store.Lease(3); err != nil {
+		t.Fatalf("Lease error: %v", err)
+	}
+	if err = store.Nack(StoredTask{ID: 3, Body: []byte{3}, CountError: 1, Error: "failed"}); err != nil {
+		t.Fatalf("Nack error: %v", err)
+	}
+	if items, err = store.List(); err != nil || len(items) != 2 {
+		t.Fatalf("Unexpected list %+v: %v", items, err)
+	}
+	if items[0].ID != 2 || !items[0].InWork || !items[0].Prelude || items[0].Body[0] != 2 {
+		t.Fatalf("Unexpected leased task: %+v", items[0])
+	}
+	if items[1].ID != 3 || items[1].InWork || items[1].CountError != 1 || items[1].Error != "failed" {
+		t.Fatalf("Unexpected returned task: %+v", items[1])
+	}
+}
+
+// TestMemoryStore Хранилище задач в памяти процесса
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+// TestMemoryStoreDefault Задачи таскера без подключённого хранилища проходят через хранилище в памяти процесса
+func TestMemoryStoreDefault(t *testing.T) {
+	var tasks = newImplementation[int]()
+	var items []StoredTask
+	var err error
+
+	tasks.Concurrent(1).Worker(func(int) error { return nil })
+	for i := 0; i < 3; i++ {
+		tasks.AddTask(i)
+	}
+	if items, err = tasks.Backend.List(); err != nil || len(items) != 3 || items[0].Body != nil {
+		t.Fatalf("Unexpected stored tasks %+v: %v", items, err)
+	}
+	tasks.Run().Wait()
+	if items, err = tasks.Backend.List(); err != nil || len(items) != 0 {
+		t.Fatalf("Done tasks not acknowledged %+v: %v", items, err)
+	}
+}
+
+// listStore Хранилище возвращающее заданный список записей задач
+type listStore struct {
+	Store
+	Items []StoredTask
+}
+
+// List Заданный список записей задач
+func (ls *listStore) List() ([]StoredTask, error) { return ls.Items, nil }
+
+// TestPersistRollback Ошибка восстановления не оставляет в очереди часть задач хранилища
+func TestPersistRollback(t *testing.T) {
+	var tasks = NewTyped[int]()
+	var body, _ = NewJSONCodec().Marshal(1)
+	var store = &listStore{Store: NewMemoryStore(), Items: []StoredTask{
+		{ID: 1, Body: body},
+		{ID: 2, Body: body},
+		{ID: 2, Body: body, Deps: []TaskID{2}},
+	}}
+	var h Handle
+	var err error
+
+	if err = tasks.Persist(store, nil); err != ErrDependencyCycle {
+		t.Fatalf("Unexpected persist error: %v", err)
+	}
+	if stats := tasks.Stats(); stats.Total != 0 || stats.Ready != 0 || stats.New != 0 {
+		t.Fatalf("Recovered tasks left in queue: %+v", stats)
+	}
+	if h, err = tasks.Submit(3); err != nil || h.ID() != 1 {
+		t.Fatalf("Unexpected task after rollback: %v", err)
+	}
+}
+
+// TestFileStoreRecovery Восстановление состояния задач из журнала и отбрасывание оборванной записи
+func TestFileStoreRecovery(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "tasks.wal")
+	var store Store
+	var items []StoredTask
+	var f *os.File
+	var err error
+
+	if store, err = OpenFileStore(path); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	testStore(t, store)
+	store.Close()
+	// Запись оборванная при аварийном завершении процесса
+	if f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600); err != nil {
+		t.Fatalf("Open journal error: %v", err)
+	}
+	f.Write(frameWal([]byte(`{"op":"ack","id":2}`))[:12])
+	f.Close()
+	if store, err = OpenFileStore(path); err != nil {
+		t.Fatalf("Reopen error: %v", err)
+	}
+	defer store.Close()
+	if items, err = store.List(); err != nil || len(items) != 2 || !items[0].InWork || items[1].CountError != 1 {
+		t.Fatalf("Unexpected recovered list %+v: %v", items, err)
+	}
+	if err = store.Enqueue(StoredTask{ID: 4}); err != nil {
+		t.Fatalf("Enqueue after recovery error: %v", err)
+	}
+	store.Close()
+	if store, err = OpenFileStore(path); err != nil {
+		t.Fatalf("Reopen error: %v", err)
+	}
+	if items, _ = store.List(); len(items) != 3 || items[2].ID != 4 {
+		t.Fatalf("Record written after torn record lost: %+v", items)
+	}
+}
+
+// TestFileStoreCompact Сжатие журнала с большим количеством устаревших записей
+func TestFileStoreCompact(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "tasks.wal")
+	var store Store
+	var items []StoredTask
+	var info os.FileInfo
+	var err error
+
+	if store, err = OpenFileStore(path); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	for i := 1; i <= walCompactMin; i++ {
+		store.Enqueue(StoredTask{ID: TaskID(i), Body: make([]byte, 64)})
+		store.Lease(TaskID(i))
+		if i > 10 {
+			store.Ack(TaskID(i))
+		}
+	}
+	// Без сжатия журнал содержит более 3000 записей размером около 300 КБ
+	if info, err = os.Stat(path); err != nil || info.Size() > 200<<10 {
+		t.Fatalf("Journal not compacted: %v", info.Size())
+	}
+	store.Close()
+	if store, err = OpenFileStore(path); err != nil {
+		t.Fatalf("Reopen error: %v", err)
+	}
+	defer store.Close()
+	if items, _ = store.List(); len(items) != 10 || !items[9].InWork {
+		t.Fatalf("Unexpected list after compaction: %d", len(items))
+	}
+}
+
+// TestFileStoreWriteFailure Оборванная при ошибке запись удаляется из журнала, ошибка сжатия не является ошибкой записи
+func TestFileStoreWriteFailure(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "tasks.wal")
+	var store Store
+	var fs *fileStore
+	var items []StoredTask
+	var good *os.File
+	var err error
+
+	if store, err = OpenFileStore(path); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	fs = store.(*fileStore)
+	if err = store.Enqueue(StoredTask{ID: 1}); err != nil {
+		t.Fatalf("Enqueue error: %v", err)
+	}
+	// Часть записи дописанная до ошибки удаляется, следующие записи не теряются при восстановлении
+	fs.File.Write(frameWal([]byte(`{"op":"ack","id":1}`))[:12])
+	fs.Rollback()
+	if err = store.Enqueue(StoredTask{ID: 2}); err != nil {
+		t.Fatalf("Enqueue after rollback error: %v", err)
+	}
+	// Журнал который не удалось обрезать больше не дописывается
+	if good, err = os.Open(path); err != nil {
+		t.Fatalf("Open journal error: %v", err)
+	}
+	fs.File, good = good, fs.File
+	if store.Enqueue(StoredTask{ID: 3}) == nil || store.Enqueue(StoredTask{ID: 4}) != fs.Failed {
+		t.Fatalf("Write to damaged journal succeeded")
+	}
+	fs.File.Close()
+	good.Close()
+	if store, err = OpenFileStore(path); err != nil {
+		t.Fatalf("Reopen error: %v", err)
+	}
+	if items, _ = store.List(); len(items) != 2 || items[1].ID != 2 {
+		t.Fatalf("Unexpected recovered list: %+v", items)
+	}
+	// Сжатие журнала невозможно, записи сохраняются, ошибка сжатия возвращается при закрытии
+	if err = os.Mkdir(path+".tmp", 0o700); err != nil {
+		t.Fatalf("Mkdir error: %v", err)
+	}
+	for i := 10; i < 10+walCompactMin; i++ {
+		if err = store.Enqueue(StoredTask{ID: TaskID(i)}); err == nil {
+			err = store.Ack(TaskID(i))
+		}
+		if err != nil {
+			t.Fatalf("Write error while compaction fails: %v", err)
+		}
+	}
+	if store.Close() == nil {
+		t.Fatalf("Compaction error not reported")
+	}
+	if err = os.Remove(path + ".tmp"); err != nil {
+		t.Fatalf("Remove error: %v", err)
+	}
+	if store, err = OpenFileStore(path); err != nil {
+		t.Fatalf("Reopen error: %v", err)
+	}
+	defer store.Close()
+	if items, _ = store.List(); len(items) != 2 {
+		t.Fatalf("Unexpected list after failed compaction: %+v", items)
+	}
+}
+
+// TestPersistRecovery Восстановление задач таскера после аварийного завершения процесса
+func TestPersistRecovery(t *testing.T) {
+	testPersistRecovery(t, OpenFileStore)
+	testPersistRecovery(t, func(path string) (Store, error) { return OpenDBStore(path) })
+}
+
+// testPersistRecovery Восстановление задач таскера из хранилища открываемого функцией open
+func testPersistRecovery(t *testing.T, open func(string) (Store, error)) {
+	var path = filepath.Join(t.TempDir(), "tasks")
+	var store Store
+	var tasks Typed[string]
+	var started = make(chan struct{})
+	var mu sync.Mutex
+	var done, bootstrapped []string
+	var err error
+
+	if store, err = open(path); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	if tasks, err = NewTypedFrom[string](store, NewJSONCodec()); err != nil {
+		t.Fatalf("NewTypedFrom error: %v", err)
+	}
+	tasks.Concurrent(1).Bootstrap(func([]string) error { return nil }).WorkerCtx(func(ctx context.Context, in string) error {
+		if in == "hang" {
+			close(started)
+			<-ctx.Done()
+		}
+		return nil
+	})
+	tasks.AddTask("hang")
+	h, _ := tasks.Submit("second")
+	tasks.Submit("third", DependsOn(h.ID()))
+	tasks.Run()
+	<-started
+	// Аварийное завершение процесса: журнал закрывается пока задача выполняется
+	store.Close()
+	tasks.Interrupt().Wait()
+
+	if store, err = open(path); err != nil {
+		t.Fatalf("Reopen error: %v", err)
+	}
+	defer store.Close()
+	if tasks, err = NewTypedFrom[string](store, NewJSONCodec()); err != nil {
+		t.Fatalf("Recovery error: %v", err)
+	}
+	if n := tasks.GetTasksNumber(); n != 3 {
+		t.Fatalf("Unexpected number of recovered tasks: %d", n)
+	}
+	tasks.Concurrent(1).Worker(func(in string) error { mu.Lock(); done = append(done, in); mu.Unlock(); return nil })
+	tasks.Bootstrap(func(items []string) error { bootstrapped = append(bootstrapped, items...); return nil })
+	tasks.Run().Wait()
+	if len(done) != 3 || done[0] != "hang" || done[2] != "third" {
+		t.Fatalf("Unexpected recovered tasks: %v", done)
+	}
+	if len(bootstrapped) != 0 {
+		t.Fatalf("Recovered tasks bootstrapped again: %v", bootstrapped)
+	}
+	if items, _ := store.List(); len(items) != 0 {
+		t.Fatalf("Completed tasks left in store: %+v", items)
+	}
+}
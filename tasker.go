@@ -4,13 +4,38 @@ package tasker
 //import "gopkg.in/webnice/log.v2"
 import (
 	"container/list"
+	"context"
 	"fmt"
 	"runtime"
+	"time"
 )
 
 // NewTasker Function create new tasker implementation
-func NewTasker() Tasker {
-	var tsk = new(implementation)
+func NewTasker() Tasker { return newImplementation[interface{}]() }
+
+// NewTyped Function create new tasker implementation with tasks of type T
+func NewTyped[T any]() Typed[T] { return newImplementation[T]() }
+
+// NewTaskerFrom Function create new tasker implementation with task store, unfinished tasks are recovered from the store
+func NewTaskerFrom(store Store, codec Codec) (Tasker, error) {
+	return NewTypedFrom[interface{}](store, codec)
+}
+
+// NewTypedFrom Function create new tasker implementation with tasks of type T and task store
+// Не завершённые задачи восстанавливаются из хранилища, объекты задач сериализуются функциями codec, nil - сериализация в JSON
+func NewTypedFrom[T any](store Store, codec Codec) (ret Typed[T], err error) {
+	var tsk = newImplementation[T]()
+
+	if err = tsk.Persist(store, codec); err != nil {
+		return
+	}
+	ret = tsk
+	return
+}
+
+// newImplementation Создание и инициализация объекта таскера
+func newImplementation[T any]() *implementation[T] {
+	var tsk = new(implementation[T])
 
 	// Default number of concurent task
 	tsk.Concurrent(runtime.NumCPU())
@@ -18,31 +43,65 @@ func NewTasker() Tasker {
 	// Initialization task list
 	tsk.Tasks = list.New()
 
+	// Задачи исчерпавшие попытки выполнения
+	tsk.DeadTasks = list.New()
+
+	// Очередь готовых к запуску задач и расписание отложенных задач
+	tsk.Ready = new(queue[T])
+	tsk.Scheduled = new(schedule[T])
+
+	// Задачи ожидающие выполнения и итог завершённых задач для проверки зависимостей
+	tsk.Pending = make(map[TaskID]*task[T])
+	tsk.Succeeded, tsk.OutcomeOrder, tsk.OutcomeLimit = make(map[TaskID]*list.Element), list.New(), outcomeLimit
+
+	// Хранилище задач по умолчанию
+	tsk.Backend = NewMemoryStore()
+
 	// Входящие задачи
-	tsk.ChanIn = make(chan *task, 1)
+	tsk.ChanIn = make(chan *task[T], 1)
 
 	// Выполненные задачи
-	tsk.ChanOut = make(chan *result, 1000)
+	tsk.ChanOut = make(chan *result[T], 1000)
 
 	// Interrupt
 	tsk.ChanInterrupt = make(chan interface{}, 1)
 
+	// Сигнал менеджеру о новых задачах
+	tsk.Wake = make(chan struct{}, 1)
+
+	// Сигнал источникам задач об освобождении места
+	tsk.Room = make(chan struct{})
+
 	return tsk
 }
 
 // Concurrent Number of concurent task
-// Устанавливать и менять значения можно пока только на не запущенном tasker
-func (tsk *implementation) Concurrent(n int) Tasker {
+// На работающем tasker количество работников изменяется сразу: новые работники запускаются,
+// лишние работники завершаются после выполнения текущей задачи. Значение n <= 0 на работающем tasker игнорируется
+func (tsk *implementation[T]) Concurrent(n int) Typed[T] {
 	tsk.Lock()
 	defer tsk.Unlock()
 	if !tsk.isWork {
 		tsk.ConcurrentProcesses = n
+		return tsk
+	}
+	if n > 0 {
+		tsk.ConcurrentProcesses = n
+		tsk.Resize()
 	}
 	return tsk
 }
 
 // Bootstrap Установка функции которая будет запущена до начала выполнения задач
-func (tsk *implementation) Bootstrap(fn BootstrapFunc) Tasker {
+func (tsk *implementation[T]) Bootstrap(fn TypedBootstrapFunc[T]) Typed[T] {
+	if fn == nil {
+		return tsk.BootstrapCtx(nil)
+	}
+	return tsk.BootstrapCtx(func(ctx context.Context, in []T) error { return fn(in) })
+}
+
+// BootstrapCtx Установка функции принимающей контекст, которая будет запущена до начала выполнения задач
+func (tsk *implementation[T]) BootstrapCtx(fn TypedBootstrapCtxFunc[T]) Typed[T] {
 	tsk.Lock()
 	defer tsk.Unlock()
 	tsk.BootstrapFn = fn
@@ -50,7 +109,25 @@ func (tsk *implementation) Bootstrap(fn BootstrapFunc) Tasker {
 }
 
 // Worker Установка функции обрабатывающей задачи
-func (tsk *implementation) Worker(fn WorkerFunc) Tasker {
+func (tsk *implementation[T]) Worker(fn TypedWorkerFunc[T]) Typed[T] {
+	if fn == nil {
+		return tsk.WorkerCtx(nil)
+	}
+	return tsk.WorkerCtx(func(ctx context.Context, in T) error { return fn(in) })
+}
+
+// WorkerCtx Установка функции обрабатывающей задачи и принимающей контекст задачи
+// Контекст задачи отменяется при вызове Interrupt() или при отмене контекста переданного в RunContext()
+func (tsk *implementation[T]) WorkerCtx(fn TypedWorkerCtxFunc[T]) Typed[T] {
+	if fn == nil {
+		return tsk.WorkerValue(nil)
+	}
+	return tsk.WorkerValue(func(ctx context.Context, in T) (interface{}, error) { return nil, fn(ctx, in) })
+}
+
+// WorkerValue Установка функции обрабатывающей задачи и возвращающей значение
+// Значение последней попытки выполнения передаётся в Result.Value
+func (tsk *implementation[T]) WorkerValue(fn TypedWorkerValueFunc[T]) Typed[T] {
 	tsk.Lock()
 	defer tsk.Unlock()
 	tsk.WorkerFn = fn
@@ -58,15 +135,95 @@ func (tsk *implementation) Worker(fn WorkerFunc) Tasker {
 }
 
 // RetryIfError Повторить запуск задачи если Worker вернул ошибку, но не более N раз. По умолчанию не повторять
-func (tsk *implementation) RetryIfError(n int) Tasker {
+func (tsk *implementation[T]) RetryIfError(n int) Typed[T] {
 	tsk.Lock()
 	defer tsk.Unlock()
 	tsk.RetryCount = n
 	return tsk
 }
 
+// Backoff Стратегия задержки перед повторным запуском задачи завершившейся ошибкой
+// По умолчанию задача повторяется без задержки
+func (tsk *implementation[T]) Backoff(p RetryPolicy) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	tsk.RetryPolicy = p
+	return tsk
+}
+
+// RetryIf Функция определяющая можно ли повторить задачу завершившуюся указанной ошибкой
+// Ошибки обёрнутые в Permanent() не повторяются независимо от результата функции
+func (tsk *implementation[T]) RetryIf(fn func(error) bool) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	tsk.RetryableFn = fn
+	return tsk
+}
+
+// Aging Повышение приоритета ожидающей задачи на единицу за каждый указанный интервал ожидания
+// Предотвращает бесконечное ожидание задач с низким приоритетом. По умолчанию 0 - приоритет не повышается
+func (tsk *implementation[T]) Aging(d time.Duration) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	tsk.Ready.SetAging(d)
+	for _, q := range tsk.Parked {
+		q.SetAging(d)
+	}
+	if tsk.KeyLimit != nil {
+		for _, q := range tsk.KeyLimit.Waiting {
+			q.SetAging(d)
+		}
+	}
+	return tsk
+}
+
+// BatchSize Максимальное количество задач передаваемых в BootstrapFunc за один вызов
+// Задачи из источника переданного в RunFrom() читаются пакетами того же размера, по умолчанию размер пакета равен Concurrent()
+func (tsk *implementation[T]) BatchSize(n int) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	if n >= 0 {
+		tsk.Batch = n
+	}
+	return tsk
+}
+
+// Timeout Максимальное время выполнения одной задачи. По умолчанию не ограничено
+// По истечении времени контекст задачи отменяется, задача завершается с ошибкой ErrTimeout
+// и повторяется как и при любой другой ошибке, если установлен RetryIfError
+// Повтор откладывается до завершения брошенного вызова функции обработки, но не больше чем на время выполнения задачи
+// Значение применяется при следующем запуске таскера
+func (tsk *implementation[T]) Timeout(d time.Duration) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	tsk.TaskTimeout = d
+	return tsk
+}
+
+// OnComplete Установка функции вызываемой по окончании выполнения каждой задачи
+// Функция вызывается из процесса Manager без захвата блокировки таскера, поэтому не должна надолго блокироваться
+func (tsk *implementation[T]) OnComplete(fn func(TypedResult[T])) Typed[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	tsk.OnCompleteFn = fn
+	return tsk
+}
+
+// Results Канал результатов выполнения задач
+// Канал закрывается после завершения текущего запуска таскера, а если таскер не запущен - после завершения следующего запуска,
+// для следующих запусков Results() необходимо вызвать повторно. Результаты не прочитанные из канала накапливаются в памяти,
+// выполнение задач не останавливается
+func (tsk *implementation[T]) Results() <-chan TypedResult[T] {
+	tsk.Lock()
+	defer tsk.Unlock()
+	if tsk.Stream == nil {
+		tsk.Stream = newResultStream[T]()
+	}
+	return tsk.Stream.Ch
+}
+
 // AddTasks Добавление среза объектов задач в очередь выполнения
-func (tsk *implementation) AddTasks(tasks []interface{}) (err error) {
+func (tsk *implementation[T]) AddTasks(tasks []T) (err error) {
 	for n := range tasks {
 		if err = tsk.AddTask(tasks[n]); err != nil {
 			return
@@ -76,30 +233,211 @@ func (tsk *implementation) AddTasks(tasks []interface{}) (err error) {
 }
 
 // AddTask Добавление одного объектов задач в очередь выполнения
-func (tsk *implementation) AddTask(t interface{}) (err error) {
+func (tsk *implementation[T]) AddTask(t T, opts ...TaskOption) (err error) {
+	_, err = tsk.Submit(t, opts...)
+	return
+}
+
+// Submit Добавление задачи в очередь выполнения с получением дескриптора задачи
+func (tsk *implementation[T]) Submit(t T, opts ...TaskOption) (Handle, error) {
+	return tsk.SubmitCtx(context.Background(), t, opts...)
+}
+
+// SubmitCtx Добавление задачи в очередь выполнения с ожиданием места в очереди при политике OverflowBlock
+// Ожидание прерывается отменой контекста, задача при этом не добавляется и возвращается ошибка контекста
+func (tsk *implementation[T]) SubmitCtx(ctx context.Context, t T, opts ...TaskOption) (ret Handle, err error) {
 	tsk.Lock()
 	defer tsk.Unlock()
-	if t == nil {
+	if err = tsk.Admit(ctx); err != nil {
+		return
+	}
+	return tsk.Add(t, opts...)
+}
+
+// Add Добавление задачи в очередь выполнения, вызывается под блокировкой таскера
+func (tsk *implementation[T]) Add(t T, opts ...TaskOption) (ret Handle, err error) {
+	var item, replaced *task[T]
+	var dropped bool
+
+	if any(t) == nil {
 		err = fmt.Errorf("Error, task is nil")
 		return
 	}
-	tsk.Tasks.PushBack(&task{Body: t})
+	tsk.LastID++
+	item = &task[T]{ID: tsk.LastID, Body: t, Index: -1, TimerIndex: -1, Completed: make(chan struct{})}
+	item.Created = time.Now()
+	for i := range opts {
+		opts[i](&item.taskParams)
+	}
+	if ret, replaced, err = tsk.Deduplicate(item); err != nil || ret != nil {
+		return
+	}
+	// Замена задачи не увеличивает очередь, поэтому политика переполнения к ней не применяется
+	if replaced == nil && tsk.IsFull() {
+		if dropped, err = tsk.Overflowed(item); err != nil {
+			return
+		}
+		if dropped {
+			ret = &handle[T]{Task: item, Parent: tsk}
+			return
+		}
+	}
+	if err = tsk.Save(item); err != nil {
+		return
+	}
+	// Задачи зависящие от заменяемой задачи ожидают новую задачу, поэтому замена не пропускает их
+	if replaced != nil {
+		tsk.Adopt(item, replaced)
+	}
+	if err = tsk.Link(item); err != nil {
+		if replaced != nil {
+			tsk.Adopt(replaced, item)
+		}
+		tsk.Ack(item)
+		return
+	}
+	// Заменяемая задача удаляется только после того, как новая задача принята
+	if replaced != nil {
+		tsk.Discard(replaced, ErrTaskReplaced)
+		for _, dependent := range item.Dependents {
+			tsk.Nack(dependent)
+		}
+	}
+	tsk.Pending[item.ID] = item
+	item.Element = tsk.Tasks.PushBack(item)
+	tsk.Claim(item)
+	tsk.Serialize(item)
+	tsk.Fresh = append(tsk.Fresh, item)
+	tsk.Signal()
+	ret = &handle[T]{Task: item, Parent: tsk}
 	return
 }
 
-// Clean Очистка всех задач в очереди
-func (tsk *implementation) Clean() Tasker {
+// AddTaskWithPriority Добавление задачи с приоритетом, задачи с большим приоритетом запускаются раньше
+// Задачи с одинаковым приоритетом запускаются в порядке добавления, по умолчанию приоритет равен 0
+func (tsk *implementation[T]) AddTaskWithPriority(t T, priority int) error {
+	return tsk.AddTask(t, WithPriority(priority))
+}
+
+// AddTaskAt Добавление задачи, которая будет запущена не раньше указанного времени
+func (tsk *implementation[T]) AddTaskAt(t T, at time.Time) error {
+	return tsk.AddTask(t, At(at))
+}
+
+// AddTaskAfter Добавление задачи, которая будет запущена не раньше чем через указанное время
+func (tsk *implementation[T]) AddTaskAfter(t T, d time.Duration) error {
+	return tsk.AddTask(t, After(d))
+}
+
+// At Опция задачи, задача будет запущена не раньше указанного времени
+func At(at time.Time) TaskOption {
+	return func(t *taskParams) { t.NotBefore = at }
+}
+
+// After Опция задачи, задача будет запущена не раньше чем через указанное время после добавления
+func After(d time.Duration) TaskOption {
+	return func(t *taskParams) { t.NotBefore = t.Created.Add(d) }
+}
+
+// WithPriority Опция задачи, приоритет задачи
+func WithPriority(priority int) TaskOption {
+	return func(t *taskParams) { t.Priority = priority }
+}
+
+// WithTimeout Опция задачи, максимальное время выполнения задачи, заменяет значение установленное в Timeout()
+func WithTimeout(d time.Duration) TaskOption {
+	return func(t *taskParams) { t.Timeout = d }
+}
+
+// Clean Очистка всех задач в очереди за исключением выполняющихся в текущее время
+// Выполняющиеся задачи завершаются своим результатом
+// Удалённые задачи не передаются в OnComplete, но функции уведомления задач вызываются без захвата блокировки
+func (tsk *implementation[T]) Clean() Typed[T] {
+	var elm, next *list.Element
+	var item *task[T]
+	var drained []*task[T]
+
 	tsk.Lock()
-	defer tsk.Unlock()
-	tsk.Tasks.Init()
+	for elm = tsk.Tasks.Front(); elm != nil; elm = next {
+		if next, item = elm.Next(), elm.Value.(*task[T]); item.InWork {
+			continue
+		}
+		tsk.Tasks.Remove(elm)
+		item.Element, item.Held = nil, false
+		delete(tsk.Pending, item.ID)
+		if tsk.Keys[item.Key] == item {
+			delete(tsk.Keys, item.Key)
+		}
+		if line := tsk.Lines[item.Line]; item.LineElement != nil && line != nil {
+			if line.Remove(item.LineElement); line.Len() == 0 {
+				delete(tsk.Lines, item.Line)
+			}
+		}
+		item.LineElement = nil
+		item.Drain()
+		tsk.Ack(item)
+		drained = append(drained, item)
+	}
+	tsk.Ready.Reset()
+	tsk.Scheduled.Reset()
+	for _, q := range tsk.Parked {
+		q.Reset()
+	}
+	tsk.Parked = nil
+	if tsk.KeyLimit != nil {
+		for _, q := range tsk.KeyLimit.Waiting {
+			q.Reset()
+		}
+		tsk.KeyLimit.Waiting = make(map[string]*queue[T])
+	}
+	tsk.Fresh = tsk.Fresh[:0]
+	tsk.Vacate()
+	tsk.Signal()
+	tsk.Unlock()
+	for _, item = range drained {
+		if item.Notify != nil {
+			item.Notify(item.Outcome)
+		}
+	}
 	return tsk
 }
 
+// Stats Количество задач в каждом из состояний
+func (tsk *implementation[T]) Stats() (ret Stats) {
+	var item *task[T]
+
+	tsk.Lock()
+	defer tsk.Unlock()
+	ret.Total, ret.Dead, ret.Dropped, ret.Workers = tsk.Tasks.Len(), tsk.DeadTasks.Len(), tsk.Dropped, len(tsk.WorkerPool)
+	ret.Stray = tsk.Strays
+	for _, item = range tsk.Pending {
+		switch {
+		case item.InWork:
+			ret.InWork++
+		case !item.Prelude:
+			ret.New++
+		case item.Waiting > 0 || item.Behind() || item.Held || item.Parked:
+			ret.Blocked++
+		case item.Index >= 0:
+			ret.Ready++
+		case item.TimerIndex >= 0 && item.Attempts == 0:
+			ret.Scheduled++
+		case item.TimerIndex >= 0:
+			ret.Delayed++
+		}
+	}
+	return
+}
+
 // GetTasksNumber Возвращает количество не завершенных задач (ожидающих выполнения или еще выполняющихся)
-func (tsk *implementation) GetTasksNumber() int { return tsk.Tasks.Len() }
+func (tsk *implementation[T]) GetTasksNumber() int {
+	tsk.Lock()
+	defer tsk.Unlock()
+	return tsk.Tasks.Len()
+}
 
 // Error Крайняя ошибка
-func (tsk *implementation) Error() error {
+func (tsk *implementation[T]) Error() error {
 	tsk.Lock()
 	defer tsk.Unlock()
 	return tsk.Err
@@ -107,17 +445,17 @@ func (tsk *implementation) Error() error {
 
 // Wait Ожидание окончания выполнения всех задач
 // Функция блокируется до окончания выполнени всех задач
-func (tsk *implementation) Wait() Tasker {
+func (tsk *implementation[T]) Wait() Typed[T] {
 	tsk.WorkerWG.Wait()
 	return tsk
 }
 
-// Interrupt Прерывания выполнения задач. Новые задачи перестают запускаться на выполнение, уже запущенные задачи будут выполнены
-func (tsk *implementation) Interrupt() Tasker {
-	tsk.Lock()
-	defer tsk.Unlock()
-	if len(tsk.ChanInterrupt) == 0 {
-		tsk.ChanInterrupt <- true
+// Interrupt Прерывания выполнения задач. Новые задачи перестают запускаться на выполнение,
+// контексты уже запущенных задач отменяются
+func (tsk *implementation[T]) Interrupt() Typed[T] {
+	select {
+	case tsk.ChanInterrupt <- true:
+	default:
 	}
 	return tsk
 }
@@ -0,0 +1,87 @@
+package tasker
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTimeout Зависшая задача освобождает работника по истечении времени и повторяется как ошибочная
+// после завершения брошенного вызова, если он завершился в пределах времени выполнения задачи
+func TestTimeout(t *testing.T) {
+	var tasks Tasker
+	var hang = make(chan interface{})
+	var calls = make(chan interface{}, 10)
+
+	tasks = NewTasker().
+		Concurrent(1).
+		Timeout(time.Second * 2 / 5).
+		RetryIfError(2).
+		Worker(func(in interface{}) error {
+			calls <- in
+			if in.(string) == "hang" {
+				<-hang
+			}
+			return nil
+		})
+	_ = tasks.AddTask("hang")
+	_ = tasks.AddTask("hang", WithTimeout(time.Second/5))
+	_ = tasks.AddTask("done")
+	if err := tasks.Run().Error(); err != nil {
+		t.Fatalf("Error run tasker: %v", err)
+	}
+	time.Sleep(time.Second * 7 / 10)
+	if stats := tasks.Stats(); len(calls) != 3 || stats.Stray != 2 || stats.Blocked != 2 {
+		t.Fatalf("Task restarted while abandoned call is running: %d calls, %+v", len(calls), stats)
+	}
+	close(hang)
+	tasks.Wait()
+	if len(calls) != 5 {
+		t.Fatalf("Unexpected number of calls: %d", len(calls))
+	}
+	if tasks.GetTasksNumber() != 0 {
+		t.Fatalf("Tasks left in queue: %d", tasks.GetTasksNumber())
+	}
+}
+
+// TestTimeoutStray Задача, брошенный вызов которой не завершается, повторяется по истечении задержки
+// и после исчерпания попыток попадает в список не выполненных задач
+func TestTimeoutStray(t *testing.T) {
+	var tasks Tasker
+	var hang = make(chan interface{})
+	var calls = make(chan interface{}, 10)
+	var done = make(chan interface{})
+	var dead []DeadLetter
+
+	defer close(hang)
+	tasks = NewTasker().
+		Concurrent(1).
+		Timeout(time.Second / 5).
+		RetryIfError(2).
+		Worker(func(in interface{}) error {
+			calls <- in
+			<-hang
+			return nil
+		})
+	_ = tasks.AddTask("hang")
+	if err := tasks.Run().Error(); err != nil {
+		t.Fatalf("Error run tasker: %v", err)
+	}
+	go func() {
+		tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 3):
+		t.Fatalf("Task held by abandoned call forever: %+v", tasks.Stats())
+	}
+	if len(calls) != 2 {
+		t.Fatalf("Unexpected number of calls: %d", len(calls))
+	}
+	if dead = tasks.DeadLetters(); len(dead) != 1 || dead[0].Error != ErrTimeout || len(dead[0].Attempts) != 2 {
+		t.Fatalf("Invalid dead letters: %+v", dead)
+	}
+	if stats := tasks.Stats(); stats.Total != 0 || stats.Stray != 2 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
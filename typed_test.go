@@ -0,0 +1,70 @@
+package tasker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+type typedJob struct {
+	N      int
+	Square int
+}
+
+// TestTyped Таскер с задачами конкретного типа без приведения типов
+func TestTyped(t *testing.T) {
+	var tasks Typed[*typedJob]
+	var prepared int
+	var results []TypedResult[*typedJob]
+	var err error
+
+	tasks = NewTyped[*typedJob]().
+		Concurrent(2).
+		Bootstrap(func(in []*typedJob) error {
+			prepared += len(in)
+			return nil
+		}).
+		WorkerCtx(func(ctx context.Context, in *typedJob) error {
+			if in.N < 0 {
+				return fmt.Errorf("Negative number %d", in.N)
+			}
+			in.Square = in.N * in.N
+			return nil
+		}).
+		OnComplete(func(rsl TypedResult[*typedJob]) { results = append(results, rsl) })
+	if err = tasks.AddTasks([]*typedJob{{N: 1}, {N: 2}, {N: 3}, {N: -1}}); err != nil {
+		t.Fatalf("AddTasks error: %v", err)
+	}
+	tasks.Run().Wait()
+	if prepared != 4 || len(results) != 4 {
+		t.Fatalf("Unexpected number of tasks, prepared: %d, completed: %d", prepared, len(results))
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	for i, expected := range []int{1, 4, 9} {
+		if results[i].Error != nil || results[i].Body.Square != expected {
+			t.Fatalf("Unexpected result %d: %+v", i, results[i])
+		}
+	}
+	if dead := tasks.DeadLetters(); len(dead) != 1 || dead[0].Body.N != -1 {
+		t.Fatalf("Unexpected dead letters: %+v", dead)
+	}
+}
+
+// TestTypedCompatibility Таскер с задачами произвольного типа совместим с Typed[interface{}]
+func TestTypedCompatibility(t *testing.T) {
+	var tasks Typed[interface{}] = NewTasker()
+	var done int
+
+	tasks.Worker(func(in interface{}) error { done += in.(int); return nil })
+	if err := tasks.AddTask(nil); err == nil {
+		t.Fatalf("Nil task accepted")
+	}
+	if err := tasks.AddTasks([]interface{}{1, 2, 3}); err != nil {
+		t.Fatalf("AddTasks error: %v", err)
+	}
+	tasks.Run().Wait()
+	if done != 6 {
+		t.Fatalf("Unexpected sum: %d", done)
+	}
+}
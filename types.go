@@ -2,69 +2,321 @@ package tasker
 
 import (
 	"container/list"
+	"context"
 	"sync"
+	"time"
 )
 
 // Tasker is an interface
-type Tasker interface {
-	AddTasks(tasks []interface{}) error // Добавление среза объектов задач в очередь выполнения
-	AddTask(task interface{}) error     // Добавление одного объектов задач в очередь выполнения
-	Bootstrap(BootstrapFunc) Tasker     // Установка функции которая будет запущена до начала выполнения задач
-	Concurrent(int) Tasker              // Concurrent Number of concurent task
-	Clean() Tasker                      // Очистка всех задач в очереди за исключением выполняющихся в текущее время
-	Error() error                       // Последняя возникшая ошибка
-	GetTasksNumber() int                // Возвращает количество не завершенных задач (ожидающих выполнения или еще выполняющихся)
-	Interrupt() Tasker                  // Прерывания выполнения задач. Новые задачи перестают запускаться на выполнение, уже запущенные задачи будут выполнены
-	IsWork() bool                       // =true - tasker выполняет задачи, =false - tasker закончил выполнение всех задач, все goroutines навершены
-	Run() Tasker                        // Запуск выполнения задач без ожидания, функция возвращает выполнение после запуска контроллера задач в отдельном процессе
-	RetryIfError(int) Tasker            // Повторить запуск задачи если Worker вернул ошибку, но не более N раз. По умолчанию не повторять
-	Worker(WorkerFunc) Tasker           // Установка функции обрабатывающей задачи
-	Wait() Tasker                       // Ожидание окончания выполнения всех задач, функция блокируется до окончания выполнени всех задач
+// Таскер с объектами задач произвольного типа
+type Tasker = Typed[interface{}]
+
+// Typed is an interface of tasker with tasks of type T
+type Typed[T any] interface {
+	AddTaskAfter(task T, d time.Duration) error                       // Добавление задачи, которая будет запущена не раньше чем через указанное время
+	AddTaskAt(task T, at time.Time) error                             // Добавление задачи, которая будет запущена не раньше указанного времени
+	AddTaskWithPriority(task T, priority int) error                   // Добавление задачи с приоритетом, задачи с большим приоритетом запускаются раньше
+	AddTasks(tasks []T) error                                         // Добавление среза объектов задач в очередь выполнения
+	AddTask(task T, opts ...TaskOption) error                         // Добавление одного объектов задач в очередь выполнения
+	AddTaskCtx(ctx context.Context, task T, opts ...TaskOption) error // Добавление задачи с ожиданием места в очереди при политике OverflowBlock, ожидание прерывается отменой контекста
+	Aging(time.Duration) Typed[T]                                     // Повышение приоритета ожидающей задачи на единицу за каждый указанный интервал ожидания
+	Autoscale(min, max int) Typed[T]                                  // Автоматическое изменение количества работников между min и max по длине очереди и времени выполнения задач
+	Backoff(RetryPolicy) Typed[T]                                     // Стратегия задержки перед повторным запуском задачи завершившейся ошибкой
+	BatchSize(int) Typed[T]                                           // Максимальное количество задач передаваемых в BootstrapFunc за один вызов и размер пакета задач читаемых из источника
+	Bootstrap(TypedBootstrapFunc[T]) Typed[T]                         // Установка функции которая будет запущена до начала выполнения задач
+	BootstrapCtx(TypedBootstrapCtxFunc[T]) Typed[T]                   // Установка функции принимающей контекст, которая будет запущена до начала выполнения задач
+	Concurrent(int) Typed[T]                                          // Concurrent Number of concurent task
+	ConcurrentBy(key func(T) string, n int) Typed[T]                  // Ограничение количества одновременно выполняющихся задач каждой группы
+	Clean() Typed[T]                                                  // Очистка всех задач в очереди за исключением выполняющихся в текущее время
+	Dedup(mode DedupMode, ttl time.Duration) Typed[T]                 // Режим обработки задач с ключом уже ожидающей или выполняющейся задачи и время хранения ключей выполненных задач
+	DeadLetters() []TypedDeadLetter[T]                                // Список задач исчерпавших попытки выполнения
+	DrainDeadLetters() []TypedDeadLetter[T]                           // Извлечение и удаление всех задач из списка исчерпавших попытки выполнения
+	Error() error                                                     // Последняя возникшая ошибка
+	GetTasksNumber() int                                              // Возвращает количество не завершенных задач (ожидающих выполнения или еще выполняющихся)
+	Interrupt() Typed[T]                                              // Прерывания выполнения задач. Новые задачи перестают запускаться на выполнение, контексты запущенных задач отменяются
+	MaxQueued(n int, policy OverflowPolicy) Typed[T]                  // Ограничение количества задач ожидающих запуска и поведение при переполнении очереди
+	Merge(fn func(queued, added T) T) Typed[T]                        // Функция объединения объектов задач с одинаковым ключом в режиме DedupMerge
+	IsWork() bool                                                     // =true - tasker выполняет задачи, =false - tasker закончил выполнение всех задач, все goroutines навершены
+	IsPaused() bool                                                   // =true - запуск задач приостановлен вызовом Pause()
+	Run() Typed[T]                                                    // Запуск выполнения задач без ожидания, функция возвращает выполнение после запуска контроллера задач в отдельном процессе
+	OnComplete(func(TypedResult[T])) Typed[T]                         // Установка функции вызываемой по окончании выполнения каждой задачи
+	OnPauseChange(func(paused bool)) Typed[T]                         // Установка функции вызываемой при приостановке и возобновлении запуска задач
+	Outcomes(limit int, ttl time.Duration) Typed[T]                   // Количество и время хранения итогов завершённых задач для проверки зависимостей добавляемых задач
+	Persist(store Store, codec Codec) error                           // Подключение хранилища задач с восстановлением не завершённых задач из хранилища
+	Pause() Typed[T]                                                  // Приостановка запуска задач без остановки работников
+	RateLimit(rps float64, burst int) Typed[T]                        // Ограничение скорости запуска задач алгоритмом корзины токенов
+	RateLimitBy(key func(T) string, rps float64, burst int) Typed[T]  // Ограничение скорости запуска задач с отдельной корзиной токенов для каждого ключа
+	Requeue(TaskID) error                                             // Возврат задачи исчерпавшей попытки выполнения в очередь выполнения
+	RequeueAll() int                                                  // Возврат всех задач исчерпавших попытки выполнения в очередь выполнения
+	Resume() Typed[T]                                                 // Возобновление запуска задач приостановленного таскера
+	Results() <-chan TypedResult[T]                                   // Канал результатов выполнения задач
+	RunContext(context.Context) Typed[T]                              // Запуск выполнения задач без ожидания с родительским контекстом, отмена контекста прерывает выполнение задач
+	RunFrom(context.Context, Source[T]) Typed[T]                      // Запуск выполнения задач читаемых из источника по мере освобождения места в очереди
+	RetryIf(func(error) bool) Typed[T]                                // Функция определяющая можно ли повторить задачу завершившуюся указанной ошибкой
+	RetryIfError(int) Typed[T]                                        // Повторить запуск задачи если Worker вернул ошибку, но не более N раз. По умолчанию не повторять
+	SerializeBy(key func(T) string) Typed[T]                          // Последовательное выполнение задач с одинаковым ключом в порядке добавления
+	Shutdown(ctx context.Context, mode ShutdownMode) ShutdownReport   // Остановка таскера в указанном режиме с ожиданием окончания остановки не дольше времени жизни контекста
+	Stats() Stats                                                     // Количество задач в каждом из состояний
+	Submit(task T, opts ...TaskOption) (Handle, error)                // Добавление задачи в очередь выполнения с получением дескриптора задачи
+	SubmitCtx(context.Context, T, ...TaskOption) (Handle, error)      // Добавление задачи с получением дескриптора и ожиданием места в очереди при политике OverflowBlock, ожидание прерывается отменой контекста
+	Timeout(time.Duration) Typed[T]                                   // Максимальное время выполнения одной задачи. По умолчанию не ограничено
+	Worker(TypedWorkerFunc[T]) Typed[T]                               // Установка функции обрабатывающей задачи
+	WorkerCtx(TypedWorkerCtxFunc[T]) Typed[T]                         // Установка функции обрабатывающей задачи и принимающей контекст задачи
+	WorkerValue(TypedWorkerValueFunc[T]) Typed[T]                     // Установка функции обрабатывающей задачи и возвращающей значение, передаваемое в Result.Value
+	Wait() Typed[T]                                                   // Ожидание окончания выполнения всех задач, функция блокируется до окончания выполнени всех задач
 }
 
 // implementation is an tasker implementation
-type implementation struct {
-	ConcurrentProcesses int              // Максимальное количество одновременно выполняющихся задач
-	Err                 error            // Последняя ошибка
-	BootstrapFn         BootstrapFunc    // Функция предпусковой обработки данных для задач
-	WorkerFn            WorkerFunc       // Функция обрабатывающая задачу
-	Tasks               *list.List       // Список задач/данных ожидающих выполнения/обработки
-	isWork              bool             // =true - tasker запущен и работает, =false - tasker остановлен
-	ChanIn              chan *task       // Канал задач для воркера
-	ChanOut             chan *result     // Выполненные задачи
-	ChanInterrupt       chan interface{} // Прерывание выполнения задач
-	WorkerPool          []worker         // Запущенные работники
-	WorkerWG            sync.WaitGroup   // Лок ожидания завершения работников
-	RetryCount          int              // Количество повторов запуска задачи в случае ошибки. По умолчанию 0 - не перезапускать
+type implementation[T any] struct {
+	ConcurrentProcesses int                      // Максимальное количество одновременно выполняющихся задач
+	Err                 error                    // Последняя ошибка
+	BootstrapFn         TypedBootstrapCtxFunc[T] // Функция предпусковой обработки данных для задач
+	WorkerFn            TypedWorkerValueFunc[T]  // Функция обрабатывающая задачу
+	Tasks               *list.List               // Список задач/данных ожидающих выполнения/обработки
+	Ready               *queue[T]                // Очередь готовых к запуску задач, упорядоченная по приоритету
+	Scheduled           *schedule[T]             // Задачи ожидающие времени запуска или окончания задержки перед повтором
+	isWork              bool                     // =true - tasker запущен и работает, =false - tasker остановлен
+	ChanIn              chan *task[T]            // Канал задач для воркера
+	ChanOut             chan *result[T]          // Выполненные задачи
+	ChanInterrupt       chan interface{}         // Прерывание выполнения задач
+	Wake                chan struct{}            // Сигнал менеджеру о появлении новых задач
+	Room                chan struct{}            // Сигнал источникам задач об освобождении места в очереди
+	Sources             int                      // Количество источников задач, из которых ещё читаются задачи
+	MaxQueue            int                      // Максимальное количество задач ожидающих запуска. По умолчанию 0 - не ограничено
+	Overflow            OverflowPolicy           // Поведение при добавлении задачи в заполненную очередь
+	Producers           int                      // Количество добавляющих задачи процессов, ожидающих места в очереди
+	Dropped             int                      // Количество задач удалённых из-за переполнения очереди
+	Limit               *bucket                  // Ограничение скорости запуска задач. По умолчанию nil - не ограничено
+	KeyLimit            *keyLimit[T]             // Ограничение скорости запуска задач по ключам. По умолчанию nil - не ограничено
+	Throttled           time.Time                // Ближайшее время снятия ограничения скорости для задач которые не удалось запустить
+	GroupKey            func(T) string           // Функция получения группы задачи для ограничения одновременно выполняющихся задач группы
+	GroupLimit          int                      // Максимальное количество одновременно выполняющихся задач одной группы
+	Running             map[string]int           // Количество выполняющихся задач по группам
+	Parked              map[string]*queue[T]     // Отложенные задачи групп, исчерпавших ограничение
+	SerialKey           func(T) string           // Функция получения ключа задачи для последовательного выполнения задач одного ключа
+	Lines               map[string]*list.List    // Очереди не завершённых задач по ключам последовательного выполнения
+	Batch               int                      // Максимальное количество задач передаваемых в BootstrapFunc за один вызов. По умолчанию 0 - не ограничено
+	InFlight            int                      // Количество задач переданных работникам, результат которых ещё не обработан
+	Strays              int                      // Количество брошенных по истечении времени и ещё не завершившихся вызовов функции обработки
+	Fresh               []*task[T]               // Новые задачи ожидающие обработки функцией BootstrapFunc
+	Outbox              []outgoing[T]            // Итоговые результаты ожидающие передачи в OnComplete
+	WorkerPool          []*worker[T]             // Запущенные работники
+	Retired             []*worker[T]             // Выведенные из работы работники, которые ещё могут выполнять задачу
+	WorkerSeq           int                      // Номер следующего запускаемого работника
+	ScaleMin            int                      // Минимальное количество работников при автоматическом изменении
+	ScaleMax            int                      // Максимальное количество работников при автоматическом изменении. По умолчанию 0 - автоматическое изменение выключено
+	ScaledAt            time.Time                // Время последней оценки количества работников
+	Latency             time.Duration            // Скользящее среднее времени выполнения задачи
+	Report              *ShutdownReport          // Отчёт о выполняющейся остановке таскера, заполняется завершёнными задачами
+	Lenient             bool                     // Остановка без отмены контекстов выполняющихся задач
+	Paused              bool                     // Запуск задач приостановлен
+	OnPauseFn           func(bool)               // Функция вызываемая при приостановке и возобновлении запуска задач
+	PauseLock           sync.Mutex               // Упорядочивание изменений состояния приостановки и вызовов функции OnPauseChange
+	Backend             Store                    // Хранилище задач, по умолчанию хранилище в памяти процесса
+	BodyCodec           Codec                    // Сериализация объектов задач для хранилища, nil - объекты задач не сериализуются
+	DedupMode           DedupMode                // Режим обработки задач с ключом уже ожидающей или выполняющейся задачи
+	DedupTTL            time.Duration            // Время хранения ключей успешно выполненных задач, 0 - ключи не хранятся
+	MergeFn             func(T, T) T             // Функция объединения объектов задач с одинаковым ключом
+	Keys                map[string]*task[T]      // Ожидающие и выполняющиеся задачи по ключам
+	Seen                map[string]*list.Element // Ключи успешно выполненных задач
+	SeenOrder           *list.List               // Ключи успешно выполненных задач в порядке окончания времени хранения
+	WorkerWG            sync.WaitGroup           // Лок ожидания завершения работников
+	RetryCount          int                      // Количество повторов запуска задачи в случае ошибки. По умолчанию 0 - не перезапускать
+	TaskTimeout         time.Duration            // Максимальное время выполнения задачи. По умолчанию 0 - не ограничено
+	Ctx                 context.Context          // Контекст текущего запуска, родительский для контекстов всех задач
+	Cancel              context.CancelFunc       // Отмена контекста текущего запуска
+	OnCompleteFn        func(TypedResult[T])     // Функция вызываемая по окончании выполнения каждой задачи
+	Stream              *resultStream[T]         // Канал результатов выполнения задач, создаётся вызовом Results() и закрывается по завершении запусков
+	Runs                int                      // Количество запусков, менеджер которых ещё не завершил работу
+	DeadTasks           *list.List               // Список задач исчерпавших попытки выполнения
+	LastID              TaskID                   // Последний присвоенный задаче идентификатор
+	Pending             map[TaskID]*task[T]      // Задачи ожидающие выполнения или выполняющиеся
+	Succeeded           map[TaskID]*list.Element // Итоги завершённых задач для проверки зависимостей добавляемых задач
+	OutcomeOrder        *list.List               // Итоги завершённых задач в порядке завершения
+	OutcomeLimit        int                      // Максимальное количество хранимых итогов завершённых задач, 0 - не ограничено
+	OutcomeTTL          time.Duration            // Время хранения итогов завершённых задач, 0 - не ограничено
+	RetryPolicy         RetryPolicy              // Стратегия задержки перед повторным запуском задачи. По умолчанию nil - без задержки
+	RetryableFn         func(error) bool         // Функция определяющая можно ли повторить задачу. По умолчанию nil - повторять при любой ошибке
 
 	sync.Mutex // Безопасненько всё делаем
 }
 
-type worker struct {
-	ID       int              // Номер работника
-	Shutdown chan interface{} // Сигнал завершения горутины
-	Done     chan interface{} // Сигнал горутина завершена
-	Parent   *implementation  // Родительский объект
+type worker[T any] struct {
+	ID       int                     // Номер работника
+	Shutdown chan interface{}        // Сигнал завершения горутины
+	Done     chan interface{}        // Сигнал горутина завершена
+	In       chan *task[T]           // Канал задач запуска, в котором работает работник
+	Fn       TypedWorkerValueFunc[T] // Функция обрабатывающая задачу
+	Ctx      context.Context         // Контекст запуска, от которого создаются контексты задач
+	Timeout  time.Duration           // Максимальное время выполнения задачи, если для задачи не указано своё
+	Parent   *implementation[T]      // Родительский объект
 }
 
 // Структура объекта задачи
-type task struct {
-	Body       interface{} // Переданный извне объект задачи
-	InWork     bool        // =true - задача находится в работе, =false - задача находится в очереди ожидания
-	Prelude    bool        // =true - задача была обработана BootstrapFunc
-	CountError int         // Количество попыток выполнить задачу завершившихся ошибкой
+type task[T any] struct {
+	ID          TaskID        // Идентификатор задачи
+	Body        T             // Переданный извне объект задачи
+	InWork      bool          // =true - задача находится в работе, =false - задача находится в очереди ожидания
+	Prelude     bool          // =true - задача была обработана BootstrapFunc
+	CountError  int           // Количество попыток выполнить задачу завершившихся ошибкой
+	Attempts    int           // Количество попыток выполнить задачу
+	Started     time.Time     // Время начала первой попытки выполнить задачу
+	History     []Attempt     // Завершённые попытки выполнить задачу
+	Failed      time.Time     // Время перемещения задачи в список исчерпавших попытки выполнения
+	Delay       time.Duration // Задержка перед последним повтором задачи
+	Index       int           // Индекс задачи в очереди готовых к запуску задач, -1 - задача не в очереди
+	TimerIndex  int           // Индекс задачи в расписании, -1 - задача не в расписании
+	Queued      time.Time     // Время помещения задачи в очередь готовых к запуску задач
+	Element     *list.Element // Элемент списка задач ожидающих выполнения
+	Err         error         // Итоговая ошибка задачи
+	Value       interface{}   // Значение возвращённое последней попыткой выполнить задачу
+	Dependents  []*task[T]    // Задачи, зависящие от задачи
+	Group       string        // Группа задачи, вычисляется при передаче задачи работнику
+	Grouped     bool          // =true - задача учтена в количестве выполняющихся задач своей группы
+	Parked      bool          // =true - задача отложена до завершения выполняющейся задачи своей группы
+	Limited     bool          // =true - задача отложена до пополнения корзины ограничения скорости своего ключа
+	LimitKey    string        // Ключ ограничения скорости отложенной задачи
+	Line        string        // Ключ последовательного выполнения задачи
+	LineElement *list.Element // Элемент очереди задач ключа последовательного выполнения
+	Waiting     int           // Количество не выполненных задач от которых зависит задача
+	Completed   chan struct{} // Закрывается по завершении задачи
+	Encoded     []byte        // Объект задачи сериализованный для хранилища, nil - объект задачи не сериализован
+	Saved       bool          // =true - задача сохранена в хранилище
+	Status      TaskStatus    // Текущее состояние задачи
+	Cancelled   bool          // =true - задача отменена вызовом Handle.Cancel()
+	Abort       func()        // Отмена контекста выполняющейся попытки выполнения задачи, nil - задача не выполняется
+	Outcome     Result        // Итоговый результат задачи, заполняется по завершении задачи
+	Stray       bool          // =true - брошенный по истечении времени вызов функции обработки задачи ещё выполняется и задерживает повтор задачи
+	Held        bool          // =true - задача не помещена в очередь до завершения брошенного вызова функции обработки или истечения задержки
 
+	taskParams // Параметры задачи, устанавливаемые опциями
 	sync.Mutex // Безопасненько всё делаем
 }
 
+// Параметры задачи не зависящие от типа объекта задачи, устанавливаются опциями TaskOption
+type taskParams struct {
+	Timeout   time.Duration // Максимальное время выполнения задачи, заменяет значение установленное в Timeout()
+	Created   time.Time     // Время добавления задачи в очередь
+	NotBefore time.Time     // Задача не может быть запущена раньше этого времени
+	Priority  int           // Приоритет задачи, задачи с большим приоритетом запускаются раньше
+	Deps      []TaskID      // Задачи, после успешного выполнения которых может быть запущена задача
+	Notify    func(Result)  // Функция вызываемая по окончании выполнения задачи, до вызова OnComplete
+	Key       string        // Ключ идемпотентности задачи, пустой ключ - задача не проверяется на повтор
+}
+
 // Структура объекта результата задачи
-type result struct {
-	Task  *task // Задача
-	Error error // Ошибка возвращённая функцией выполнявшей задачу
+type result[T any] struct {
+	Task     *task[T]    // Задача
+	Error    error       // Ошибка возвращённая функцией выполнявшей задачу
+	Value    interface{} // Значение возвращённое функцией выполнявшей задачу
+	Started  time.Time   // Время начала выполнения
+	Finished time.Time   // Время окончания выполнения
+	WorkerID int         // Номер работника выполнявшего задачу
+	Aborted  bool        // =true - попытка прервана отменой контекста запуска, например остановкой ShutdownAbort
+}
+
+// Result Итоговый результат выполнения задачи с объектом задачи произвольного типа
+type Result = TypedResult[interface{}]
+
+// TypedResult Итоговый результат выполнения задачи
+// Формируется когда задача выполнена успешно или исчерпаны попытки её выполнения
+type TypedResult[T any] struct {
+	ID       TaskID      // Идентификатор задачи
+	Body     T           // Переданный извне объект задачи
+	Error    error       // Ошибка последней попытки выполнения, nil если задача выполнена успешно
+	Value    interface{} // Значение возвращённое последней попыткой выполнения функцией установленной WorkerValue()
+	Attempts int         // Количество попыток выполнения задачи
+	Started  time.Time   // Время начала первой попытки выполнения
+	Finished time.Time   // Время окончания последней попытки выполнения
+	WorkerID int         // Номер работника выполнявшего последнюю попытку
+}
+
+// Итоговый результат задачи вместе с функцией уведомления о завершении задачи
+type outgoing[T any] struct {
+	Result TypedResult[T] // Итоговый результат
+	Notify func(Result)   // Функция уведомления о завершении задачи
+}
+
+// Untyped Итоговый результат с объектом задачи произвольного типа
+func (rsl TypedResult[T]) Untyped() Result {
+	return Result{
+		ID:       rsl.ID,
+		Body:     rsl.Body,
+		Error:    rsl.Error,
+		Value:    rsl.Value,
+		Attempts: rsl.Attempts,
+		Started:  rsl.Started,
+		Finished: rsl.Finished,
+		WorkerID: rsl.WorkerID,
+	}
+}
+
+// TaskID Идентификатор задачи, уникален в пределах таскера
+type TaskID uint64
+
+// Attempt Попытка выполнения задачи
+type Attempt struct {
+	Started  time.Time // Время начала попытки
+	Finished time.Time // Время окончания попытки
+	WorkerID int       // Номер работника выполнявшего попытку
+	Error    error     // Ошибка возвращённая функцией выполнявшей задачу
 }
 
+// DeadLetter Задача с объектом произвольного типа исчерпавшая попытки выполнения
+type DeadLetter = TypedDeadLetter[interface{}]
+
+// TypedDeadLetter Задача исчерпавшая попытки выполнения
+type TypedDeadLetter[T any] struct {
+	ID       TaskID    // Идентификатор задачи
+	Body     T         // Переданный извне объект задачи
+	Attempts []Attempt // Все попытки выполнения задачи
+	Error    error     // Итоговая ошибка задачи
+	Created  time.Time // Время добавления задачи в очередь
+	Failed   time.Time // Время перемещения задачи в список исчерпавших попытки выполнения
+}
+
+// Stats Количество задач в каждом из состояний
+type Stats struct {
+	Total     int // Всего не завершенных задач, значение GetTasksNumber()
+	New       int // Задачи ожидающие предварительной обработки функцией BootstrapFunc
+	Blocked   int // Задачи ожидающие выполнения задач от которых зависят, предыдущей задачи своего ключа, завершения брошенного вызова или задачи своей группы
+	Scheduled int // Отложенные задачи ожидающие времени первого запуска
+	Delayed   int // Задачи ожидающие окончания задержки перед повтором
+	Ready     int // Задачи готовые к запуску
+	InWork    int // Выполняющиеся задачи
+	Dead      int // Задачи исчерпавшие попытки выполнения
+	Workers   int // Количество работников текущего запуска
+	Dropped   int // Задачи удалённые из-за переполнения очереди, счётчик за всё время работы таскера
+	Stray     int // Вызовы функции обработки брошенные по истечении времени выполнения и ещё не завершившиеся
+}
+
+// TaskOption Опция задачи, передаваемая при добавлении задачи в очередь
+type TaskOption func(*taskParams)
+
 // BootstrapFunc Тип функции которая будет запущена до начала выполнения задач
-type BootstrapFunc func([]interface{}) error
+type BootstrapFunc = TypedBootstrapFunc[interface{}]
 
 // WorkerFunc Тип функции выполняющей задачу
-type WorkerFunc func(interface{}) error
+type WorkerFunc = TypedWorkerFunc[interface{}]
+
+// BootstrapCtxFunc Тип функции которая будет запущена до начала выполнения задач и принимает контекст запуска
+type BootstrapCtxFunc = TypedBootstrapCtxFunc[interface{}]
+
+// WorkerCtxFunc Тип функции выполняющей задачу и принимающей контекст задачи
+type WorkerCtxFunc = TypedWorkerCtxFunc[interface{}]
+
+// TypedBootstrapFunc Тип функции которая будет запущена до начала выполнения задач типа T
+type TypedBootstrapFunc[T any] func([]T) error
+
+// TypedWorkerFunc Тип функции выполняющей задачу типа T
+type TypedWorkerFunc[T any] func(T) error
+
+// TypedBootstrapCtxFunc Тип функции которая будет запущена до начала выполнения задач типа T и принимает контекст запуска
+type TypedBootstrapCtxFunc[T any] func(context.Context, []T) error
+
+// TypedWorkerCtxFunc Тип функции выполняющей задачу типа T и принимающей контекст задачи
+// Контекст отменяется при вызове Interrupt() или при отмене родительского контекста переданного в RunContext()
+type TypedWorkerCtxFunc[T any] func(context.Context, T) error
+
+// TypedWorkerValueFunc Тип функции выполняющей задачу типа T, принимающей контекст задачи и возвращающей значение
+type TypedWorkerValueFunc[T any] func(context.Context, T) (interface{}, error)
@@ -0,0 +1,373 @@
+package tasker
+
+//import "gopkg.in/webnice/debug.v1"
+//import "gopkg.in/webnice/log.v2"
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Размер заголовка записи журнала: длина записи и контрольная сумма
+const walHeaderSize = 8
+
+// Максимальный размер записи журнала, запись большего размера считается повреждённой
+const walMaxRecord = 64 << 20
+
+// Минимальное количество устаревших записей журнала, после которого журнал может быть сжат
+const walCompactMin = 1024
+
+// Операции журнала
+const (
+	walEnqueue = "enqueue"
+	walLease   = "lease"
+	walAck     = "ack"
+)
+
+// fileStore Хранилище задач в файле журнала упреждающей записи
+// Каждое изменение дописывается в конец журнала и сбрасывается на диск до возврата из функции
+// Оборванная при аварийном завершении процесса запись журнала отбрасывается при открытии
+type fileStore struct {
+	Path    string                // Путь к файлу журнала
+	File    *os.File              // Открытый на дозапись файл журнала
+	Tasks   map[TaskID]StoredTask // Состояние задач, восстановленное из журнала
+	Garbage int                   // Количество записей журнала, не описывающих текущее состояние задач
+	Size    int64                 // Размер журнала после последней целой записи
+	Failed  error                 // Ошибка после которой журнал не может быть дописан, nil - журнал исправен
+	Damaged error                 // Ошибка последнего сжатия журнала, возвращается функцией Close()
+	Retry   int                   // Количество устаревших записей, после которого повторяется не удавшееся сжатие журнала
+	sync.Mutex
+}
+
+// walRecord Запись журнала
+type walRecord struct {
+	Op   string      `json:"op"`             // Операция
+	ID   TaskID      `json:"id"`             // Идентификатор задачи
+	Task *StoredTask `json:"task,omitempty"` // Запись задачи для операции добавления
+}
+
+// OpenFileStore Открытие или создание хранилища задач в файле журнала упреждающей записи
+// Состояние задач восстанавливается из журнала, журнал с большим количеством устаревших записей сжимается
+func OpenFileStore(path string) (ret Store, err error) {
+	var fs = &fileStore{Path: path, Tasks: make(map[TaskID]StoredTask)}
+
+	if err = fs.Replay(); err != nil {
+		return
+	}
+	if fs.Garbage > 0 {
+		if err = fs.Compact(); err != nil {
+			return
+		}
+	}
+	if err = fs.Reopen(); err != nil {
+		return
+	}
+	ret = fs
+	return
+}
+
+// Reopen Открытие журнала на дозапись и получение размера журнала
+func (fs *fileStore) Reopen() (err error) {
+	var fi os.FileInfo
+
+	if fs.File, err = os.OpenFile(fs.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600); err != nil {
+		fs.File, err = nil, fmt.Errorf("Open task journal error: %s", err)
+		return
+	}
+	if fi, err = fs.File.Stat(); err != nil {
+		_ = fs.File.Close()
+		fs.File, err = nil, fmt.Errorf("Stat task journal error: %s", err)
+		return
+	}
+	fs.Size = fi.Size()
+	return
+}
+
+// Replay Восстановление состояния задач из журнала, оборванная запись в конце журнала отбрасывается
+func (fs *fileStore) Replay() (err error) {
+	var f *os.File
+	var rd *bufio.Reader
+	var rec walRecord
+	var data []byte
+	var offset int64
+	var size int
+
+	if f, err = os.OpenFile(fs.Path, os.O_RDWR|os.O_CREATE, 0o600); err != nil {
+		err = fmt.Errorf("Open task journal error: %s", err)
+		return
+	}
+	defer func() {
+		if e := f.Close(); err == nil && e != nil {
+			err = fmt.Errorf("Close task journal error: %s", e)
+		}
+	}()
+	rd = bufio.NewReader(f)
+	for {
+		if data, size, err = readWal(rd); err != nil {
+			break
+		}
+		if err = json.Unmarshal(data, &rec); err != nil {
+			break
+		}
+		fs.Apply(rec)
+		offset += int64(size)
+		rec = walRecord{}
+	}
+	if errors.Is(err, io.EOF) {
+		err = nil
+		return
+	}
+	// Оборванная или повреждённая запись, журнал обрезается до последней целой записи
+	if err = f.Truncate(offset); err != nil {
+		err = fmt.Errorf("Truncate task journal error: %s", err)
+	}
+	return
+}
+
+// readWal Чтение одной записи журнала, io.EOF - журнал прочитан полностью
+func readWal(rd *bufio.Reader) (data []byte, size int, err error) {
+	var header [walHeaderSize]byte
+	var length uint32
+
+	if _, err = io.ReadFull(rd, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("Torn task journal record header")
+		}
+		return
+	}
+	if length = binary.LittleEndian.Uint32(header[0:4]); length > walMaxRecord {
+		err = fmt.Errorf("Task journal record is too large: %d", length)
+		return
+	}
+	data = make([]byte, length)
+	if _, err = io.ReadFull(rd, data); err != nil {
+		err = fmt.Errorf("Torn task journal record: %s", err)
+		return
+	}
+	if crc32.ChecksumIEEE(data) != binary.LittleEndian.Uint32(header[4:8]) {
+		err = fmt.Errorf("Task journal record checksum mismatch")
+		return
+	}
+	size = walHeaderSize + len(data)
+	return
+}
+
+// frameWal Запись журнала с заголовком из длины записи и контрольной суммы
+func frameWal(data []byte) (ret []byte) {
+	ret = make([]byte, walHeaderSize, walHeaderSize+len(data))
+	binary.LittleEndian.PutUint32(ret[0:4], uint32(len(data)))
+	binary.LittleEndian.PutUint32(ret[4:8], crc32.ChecksumIEEE(data))
+	ret = append(ret, data...)
+	return
+}
+
+// Apply Применение записи журнала к состоянию задач
+func (fs *fileStore) Apply(rec walRecord) {
+	var st StoredTask
+	var ok bool
+
+	switch rec.Op {
+	case walEnqueue:
+		if rec.Task == nil {
+			return
+		}
+		if _, ok = fs.Tasks[rec.Task.ID]; ok {
+			fs.Garbage++
+		}
+		fs.Tasks[rec.Task.ID] = *rec.Task
+	case walLease:
+		if st, ok = fs.Tasks[rec.ID]; ok {
+			st.InWork, st.Prelude = true, true
+			fs.Tasks[rec.ID] = st
+		}
+		fs.Garbage++
+	case walAck:
+		// Устаревают и запись подтверждения, и запись добавления задачи
+		if _, ok = fs.Tasks[rec.ID]; ok {
+			delete(fs.Tasks, rec.ID)
+			fs.Garbage++
+		}
+		fs.Garbage++
+	}
+}
+
+// Write Дозапись в журнал и применение записи к состоянию задач
+// Оборванная при ошибке записи запись удаляется из журнала, чтобы следующие записи не оказались за ней.
+// Ошибка сжатия журнала не является ошибкой записи, так как запись уже сохранена, она возвращается функцией Close()
+func (fs *fileStore) Write(rec walRecord) (err error) {
+	var data []byte
+
+	fs.Lock()
+	defer fs.Unlock()
+	if fs.Failed != nil {
+		err = fs.Failed
+		return
+	}
+	if fs.File == nil {
+		err = fmt.Errorf("Task journal is closed")
+		return
+	}
+	if data, err = json.Marshal(rec); err != nil {
+		err = fmt.Errorf("Encode task journal record error: %s", err)
+		return
+	}
+	data = frameWal(data)
+	if _, err = fs.File.Write(data); err != nil {
+		err = fmt.Errorf("Write task journal error: %s", err)
+	} else if err = fs.File.Sync(); err != nil {
+		err = fmt.Errorf("Sync task journal error: %s", err)
+	}
+	if err != nil {
+		fs.Rollback()
+		return
+	}
+	fs.Size += int64(len(data))
+	fs.Apply(rec)
+	if fs.Garbage >= walCompactMin && fs.Garbage > 2*len(fs.Tasks) && fs.Garbage >= fs.Retry {
+		// Не удавшееся сжатие повторяется после удвоения количества устаревших записей
+		if fs.Damaged = fs.Rotate(); fs.Damaged != nil {
+			fs.Retry = 2 * fs.Garbage
+		}
+	}
+	return
+}
+
+// Rollback Удаление из журнала оборванной записи, если журнал не удалось обрезать, журнал больше не дописывается
+func (fs *fileStore) Rollback() {
+	var err error
+
+	if err = fs.File.Truncate(fs.Size); err == nil {
+		err = fs.File.Sync()
+	}
+	if err != nil {
+		fs.Failed = fmt.Errorf("Task journal is damaged by failed write, truncate error: %s", err)
+	}
+}
+
+// Rotate Сжатие журнала открытого хранилища и повторное открытие журнала на дозапись
+// При ошибке сжатия журнал открывается повторно и дописывается без сжатия
+func (fs *fileStore) Rotate() (err error) {
+	if err = fs.File.Close(); err != nil {
+		err = fmt.Errorf("Close task journal error: %s", err)
+	} else {
+		err = fs.Compact()
+	}
+	fs.File = nil
+	if e := fs.Reopen(); e != nil {
+		fs.Failed, err = e, e
+	}
+	return
+}
+
+// Compact Запись текущего состояния задач в новый журнал и атомарная замена старого журнала
+func (fs *fileStore) Compact() (err error) {
+	var tmp = fs.Path + ".tmp"
+	var f *os.File
+	var wr *bufio.Writer
+	var data []byte
+	var items []StoredTask
+
+	if f, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600); err != nil {
+		err = fmt.Errorf("Create task journal error: %s", err)
+		return
+	}
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+	for _, st := range fs.Tasks {
+		items = append(items, st)
+	}
+	sortStored(items)
+	wr = bufio.NewWriter(f)
+	for i := range items {
+		if data, err = json.Marshal(walRecord{Op: walEnqueue, ID: items[i].ID, Task: &items[i]}); err != nil {
+			err = fmt.Errorf("Encode task journal record error: %s", err)
+			return
+		}
+		_, _ = wr.Write(frameWal(data))
+	}
+	if err = wr.Flush(); err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		err = fmt.Errorf("Write task journal error: %s", err)
+		return
+	}
+	if err = f.Close(); err != nil {
+		err = fmt.Errorf("Close task journal error: %s", err)
+		return
+	}
+	f = nil
+	if err = os.Rename(tmp, fs.Path); err != nil {
+		err = fmt.Errorf("Replace task journal error: %s", err)
+		return
+	}
+	syncDir(filepath.Dir(fs.Path))
+	fs.Garbage = 0
+	return
+}
+
+// syncDir Сброс на диск каталога после переименования файла, ошибка не критична
+func syncDir(dir string) {
+	var d *os.File
+	var err error
+
+	if d, err = os.Open(dir); err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// Enqueue Добавление или замена записи задачи
+func (fs *fileStore) Enqueue(st StoredTask) error {
+	st.InWork = false
+	return fs.Write(walRecord{Op: walEnqueue, ID: st.ID, Task: &st})
+}
+
+// Lease Отметка о передаче задачи работнику
+func (fs *fileStore) Lease(id TaskID) error { return fs.Write(walRecord{Op: walLease, ID: id}) }
+
+// Ack Удаление записи завершённой задачи
+func (fs *fileStore) Ack(id TaskID) error { return fs.Write(walRecord{Op: walAck, ID: id}) }
+
+// Nack Возврат задачи в очередь
+func (fs *fileStore) Nack(st StoredTask) error { return fs.Enqueue(st) }
+
+// List Все записи задач в порядке возрастания идентификаторов
+func (fs *fileStore) List() (ret []StoredTask, err error) {
+	fs.Lock()
+	defer fs.Unlock()
+	ret = make([]StoredTask, 0, len(fs.Tasks))
+	for _, st := range fs.Tasks {
+		ret = append(ret, st)
+	}
+	sortStored(ret)
+	return
+}
+
+// Close Закрытие файла журнала
+func (fs *fileStore) Close() (err error) {
+	fs.Lock()
+	defer fs.Unlock()
+	if fs.File == nil {
+		return fs.Damaged
+	}
+	if err = fs.File.Close(); err != nil {
+		err = fmt.Errorf("Close task journal error: %s", err)
+	}
+	if fs.File = nil; err == nil {
+		err = fs.Damaged
+	}
+	return
+}
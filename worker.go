@@ -3,43 +3,148 @@ package tasker
 //import "gopkg.in/webnice/log.v2"
 //import "gopkg.in/webnice/debug.v1"
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 )
 
 // Do Реализация воркера, горутина
-func (w *worker) Do() {
-	var t *task
-	var r *result
-	var done bool
+// Задачи оставшиеся в канале после сигнала завершения возвращаются в очередь менеджером
+func (w *worker[T]) Do() {
+	var t *task[T]
+	var r *result[T]
 
 	defer func() { w.Done <- true }()
 	for {
-		if done && len(w.Parent.ChanIn) == 0 {
-			break
-		}
 		select {
 		case <-w.Shutdown:
-			done = true
-		case t = <-w.Parent.ChanIn:
-			r = &result{Task: t}
-			if w.Parent.WorkerFn != nil {
-				if r.Error = w.Run(w.Parent.WorkerFn, t); r.Error != nil {
-					t.CountError++
-				}
+			return
+		case t = <-w.In:
+			r = &result[T]{Task: t, WorkerID: w.ID, Started: time.Now()}
+			t.Lock()
+			// Задача отменённая пока находилась в канале работника не запускается
+			if t.Cancelled {
+				r.Error = ErrTaskCancelled
+			} else if t.Attempts, t.Status = t.Attempts+1, StatusRunning; t.Started.IsZero() {
+				t.Started = r.Started
 			}
+			t.Unlock()
+			if w.Fn != nil && r.Error == nil {
+				r.Value, r.Error = w.Run(w.Fn, t)
+			}
+			r.Finished = time.Now()
+			r.Aborted = errors.Is(r.Error, context.Canceled) && w.Ctx.Err() != nil
 			w.Parent.ChanOut <- r
 		}
 	}
 }
 
-// Run Безопасный запуск внешнего воркера
-func (w *worker) Run(f WorkerFunc, t *task) (err error) {
+// Run Запуск внешнего воркера с ограничением времени выполнения
+// Каждая задача получает собственный контекст, производный от контекста запуска
+// Если время выполнения истекло, воркер не дожидается завершения внешней функции и освобождается,
+// брошенный вызов учитывается функцией Abandon
+func (w *worker[T]) Run(f TypedWorkerValueFunc[T], t *task[T]) (value interface{}, err error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var timeout = w.Timeout
+	var done = make(chan *result[T], 1)
+	var r *result[T]
+
+	if t.Timeout > 0 {
+		timeout = t.Timeout
+	}
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(w.Ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(w.Ctx)
+	}
+	defer cancel()
+	// Задача могла быть отменена после передачи работнику, но до установки функции отмены
+	t.Lock()
+	if t.Abort = cancel; t.Cancelled {
+		cancel()
+	}
+	t.Unlock()
+	defer func() {
+		t.Lock()
+		t.Abort = nil
+		t.Unlock()
+	}()
+	go func() {
+		var r = new(result[T])
+		r.Value, r.Error = w.Call(ctx, f, t)
+		done <- r
+	}()
+	select {
+	case r = <-done:
+		if value, err = r.Value, r.Error; err != nil && w.IsTimeout(ctx) {
+			err = ErrTimeout
+		}
+	case <-ctx.Done():
+		if err = ErrTimeout; !w.IsTimeout(ctx) {
+			r = <-done
+			value, err = r.Value, r.Error
+		} else {
+			w.Parent.Abandon(t, done, timeout)
+		}
+	}
+
+	return
+}
+
+// Abandon Учёт вызова функции обработки задачи, брошенного по истечении времени выполнения
+// Работник освобождается, но задача не запускается повторно, пока брошенный вызов не завершится.
+// Задержка повтора ограничена временем выполнения задачи: если брошенный вызов не завершился за это время,
+// задача возвращается в очередь, а вызов учитывается как брошенный до своего завершения
+func (tsk *implementation[T]) Abandon(item *task[T], done <-chan *result[T], hold time.Duration) {
+	tsk.Lock()
+	item.Stray = true
+	tsk.Strays++
+	tsk.Unlock()
+	go func() {
+		var timer = time.NewTimer(hold)
+		var finished bool
+
+		defer timer.Stop()
+		select {
+		case <-done:
+			finished = true
+		case <-timer.C:
+		}
+		tsk.Lock()
+		if item.Stray = false; finished {
+			tsk.Strays--
+		}
+		if item.Held && tsk.Pending[item.ID] == item {
+			tsk.Enqueue(item)
+		}
+		item.Held = false
+		tsk.Unlock()
+		tsk.Signal()
+		if finished {
+			return
+		}
+		<-done
+		tsk.Lock()
+		tsk.Strays--
+		tsk.Unlock()
+	}()
+}
+
+// IsTimeout Контекст задачи отменён по истечении времени выполнения, а не прерыванием
+func (w *worker[T]) IsTimeout(ctx context.Context) bool {
+	return ctx.Err() == context.DeadlineExceeded && w.Ctx.Err() == nil
+}
+
+// Call Безопасный вызов внешней функции
+func (w *worker[T]) Call(ctx context.Context, f TypedWorkerValueFunc[T], t *task[T]) (value interface{}, err error) {
 	defer func() {
 		if e := recover(); e != nil {
 			err = fmt.Errorf("Recovery panic call external worker: %v", e)
 			return
 		}
 	}()
-	err = f(t.Body)
+	value, err = f(ctx, t.Body)
 	return
 }